@@ -0,0 +1,271 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/internal/sources"
+	"github.com/haroldadmin/getignore/pkg/git"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/haroldadmin/getignore/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var ErrUnknownSource = errors.New("unknown-source")
+
+var sourceOptions sources.Options
+
+var CacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the cached gitignore repositories",
+	Long: `The cache command shows and manages the gitignore repositories
+cloned by getignore.
+
+Subcommands act on every configured git source, or only on the
+sources named as arguments.`,
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status [source...]",
+	Short: "Show the cached gitignore repositories",
+	Long: `Shows the path, remote, checked out commit, last update, disk size
+and number of templates of each cached gitignore repository.
+Never touches the network or modifies the repositories.`,
+	RunE: Status,
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update [source...]",
+	Short: "Update the cached gitignore repositories now",
+	Long: `Pulls the latest changes into each gitignore repository,
+regardless of --update-interval. Unlike the other commands, which fall
+back to the cached templates, it fails if a repository can not be updated.`,
+	RunE: Update,
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean [source...]",
+	Short: "Delete the cached gitignore repositories and clone them again",
+	Long: `Deletes each gitignore repository and clones it again.
+Directories which were not created by getignore are never deleted.`,
+	RunE: Clean,
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [source...]",
+	Short: "Check the cached gitignore repositories for missing objects and local changes",
+	Long: `Reads every object of the checked out commit of each gitignore
+repository, and checks its worktree for local changes.
+Repositories cloned by getignore are repaired; others are only reported.`,
+	RunE: Verify,
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc [source...]",
+	Short: "Prune and repack the cached gitignore repositories",
+	Long: `Deletes unreachable objects from each gitignore repository and
+repacks the remaining ones to save disk space.
+Shallow clones are skipped.`,
+	RunE: GC,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{statusCmd, updateCmd, cleanCmd, verifyCmd, gcCmd} {
+		sources.AddFlags(cmd, &sourceOptions)
+		CacheCmd.AddCommand(cmd)
+	}
+}
+
+// selectSources returns the configured git sources named in [names], or all
+// of them when no names are given
+func selectSources(names []string) ([]sources.GitSource, error) {
+	gitSources, err := sourceOptions.GitSources()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return gitSources, nil
+	}
+
+	selected := make([]sources.GitSource, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, source := range gitSources {
+			if source.Name == name {
+				selected = append(selected, source)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownSource, name)
+		}
+	}
+
+	return selected, nil
+}
+
+func Status(cmd *cobra.Command, args []string) error {
+	logger := logs.CreateLogger("cmd.cache.status")
+	gitSources, err := selectSources(args)
+	if err != nil {
+		return err
+	}
+
+	for index, source := range gitSources {
+		if index > 0 {
+			fmt.Println()
+		}
+		fmt.Println(source.Name)
+
+		repository, status, err := git.Status(cmd.Context(), source.Options)
+		if errors.Is(err, git.ErrNoCache) {
+			fmt.Printf("  Path:        %s\n", source.Options.RepositoryDir)
+			fmt.Printf("  Remote:      %s\n", source.Options.RemoteURL)
+			fmt.Println("  Not cloned yet")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("source %q: %w", source.Name, err)
+		}
+
+		// Templates are counted without an index, which would be written
+		// to the repository
+		templates := 0
+		templateSource := gitignore.RepositorySource(source.Name, repository)
+		service, err := gitignore.CreateFromSources(cmd.Context(), templateSource)
+		if err != nil {
+			logger.Warnf("failed to count templates of %q: %v", source.Name, err)
+		} else {
+			templates = len(service.GetAll())
+		}
+
+		fmt.Printf("  Path:        %s\n", status.Path)
+		fmt.Printf("  Remote:      %s\n", status.RemoteURL)
+		fmt.Printf("  Commit:      %s\n", status.Head)
+		fmt.Printf("  Last update: %s\n", describeLastUpdate(status.LastUpdate))
+		fmt.Printf("  Disk size:   %s\n", utils.FormatBytes(status.DiskSize))
+		fmt.Printf("  Templates:   %d\n", templates)
+		if status.Shallow {
+			fmt.Println("  Shallow:     yes")
+		}
+		if !status.Managed {
+			fmt.Println("  Managed:     no (not cloned by getignore, never repaired or deleted)")
+		}
+	}
+
+	return nil
+}
+
+func describeLastUpdate(lastUpdate time.Time) string {
+	if lastUpdate.IsZero() {
+		return "unknown"
+	}
+
+	age := time.Since(lastUpdate).Round(time.Minute)
+	return fmt.Sprintf("%s (%s ago)", lastUpdate.Format(utils.DateFormat), age)
+}
+
+func Update(cmd *cobra.Command, args []string) error {
+	gitSources, err := selectSources(args)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range gitSources {
+		options := source.Options
+		finish := sources.TrackProgress(source.Name, &options)
+		repository, err := git.ForceUpdate(cmd.Context(), options)
+		finish()
+		if err != nil {
+			return fmt.Errorf("source %q: %w", source.Name, err)
+		}
+
+		head, err := git.HeadCommit(repository)
+		if err != nil {
+			return fmt.Errorf("source %q: %w", source.Name, err)
+		}
+		fmt.Printf("Updated %s (commit %s)\n", source.Name, head)
+	}
+
+	return nil
+}
+
+func Clean(cmd *cobra.Command, args []string) error {
+	gitSources, err := selectSources(args)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range gitSources {
+		options := source.Options
+		finish := sources.TrackProgress(source.Name, &options)
+		repository, err := git.Clean(cmd.Context(), options)
+		finish()
+		if err != nil {
+			return fmt.Errorf("source %q: %w", source.Name, err)
+		}
+
+		head, err := git.HeadCommit(repository)
+		if err != nil {
+			return fmt.Errorf("source %q: %w", source.Name, err)
+		}
+		fmt.Printf("Cloned %s again (commit %s)\n", source.Name, head)
+	}
+
+	return nil
+}
+
+func Verify(cmd *cobra.Command, args []string) error {
+	gitSources, err := selectSources(args)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range gitSources {
+		options := source.Options
+		finish := sources.TrackProgress(source.Name, &options)
+		repository, err := git.Verify(cmd.Context(), options)
+		finish()
+		if err != nil {
+			return fmt.Errorf("source %q: %w", source.Name, err)
+		}
+
+		head, err := git.HeadCommit(repository)
+		if err != nil {
+			return fmt.Errorf("source %q: %w", source.Name, err)
+		}
+		fmt.Printf("Verified %s (commit %s)\n", source.Name, head)
+	}
+
+	return nil
+}
+
+func GC(cmd *cobra.Command, args []string) error {
+	gitSources, err := selectSources(args)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range gitSources {
+		before, after, err := git.GC(cmd.Context(), source.Options)
+		if errors.Is(err, git.ErrShallowRepository) {
+			fmt.Printf("Skipped %s: shallow clones can not be repacked\n", source.Name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("source %q: %w", source.Name, err)
+		}
+
+		fmt.Printf(
+			"Repacked %s: %s -> %s\n",
+			source.Name,
+			utils.FormatBytes(before),
+			utils.FormatBytes(after),
+		)
+	}
+
+	return nil
+}
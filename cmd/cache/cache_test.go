@@ -0,0 +1,23 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/haroldadmin/getignore/cmd/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("it should have a usage line", func(t *testing.T) {
+		usage := cache.CacheCmd.Use
+		assert.NotEmpty(t, usage)
+	})
+
+	t.Run("it should have status, update, clean, verify and gc subcommands", func(t *testing.T) {
+		names := []string{}
+		for _, cmd := range cache.CacheCmd.Commands() {
+			names = append(names, cmd.Name())
+		}
+		assert.ElementsMatch(t, []string{"status", "update", "clean", "verify", "gc"}, names)
+	})
+}
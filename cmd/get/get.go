@@ -1,20 +1,21 @@
 package get
 
 import (
+	"context"
 	"errors"
 	"os"
+	"strings"
 
 	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/haroldadmin/getignore/internal/logs"
-	"github.com/haroldadmin/getignore/pkg/git"
+	"github.com/haroldadmin/getignore/internal/sources"
 	"github.com/haroldadmin/getignore/pkg/gitignore"
 	"github.com/spf13/cobra"
 )
 
 var (
-	repoDir      string
-	updateRepo   bool
-	appendToFile bool
+	sourceOptions sources.Options
+	appendToFile  bool
 )
 
 var GetCmd = &cobra.Command{
@@ -23,9 +24,12 @@ var GetCmd = &cobra.Command{
 	Long: `Non-interactively fetches the .gitignore file with the exact name. 
 Exits with an error if no match is found.
 
+Several names can be given as separate arguments or as a comma separated
+list, such as "go,node". Their contents are added in order.
+
 Use this command if you're sure of the exact name of the .gitignore file
 you're looking for.`,
-	Args: cobra.ExactArgs(1),
+	Args: cobra.MinimumNArgs(1),
 	RunE: RunGet,
 }
 
@@ -39,50 +43,45 @@ func init() {
 Creates a new .gitignore file if it doesn't exist.`,
 	)
 
-	GetCmd.Flags().StringVar(
-		&repoDir,
-		"repo-dir",
-		git.DefaultRepoDir(),
-		"Set custom directory for gitignore repository",
-	)
-
-	GetCmd.Flags().BoolVar(
-		&updateRepo,
-		"update-repo",
-		true,
-		"Update the gitignore repository with upstream changes",
-	)
+	sources.AddFlags(GetCmd, &sourceOptions)
 }
 
 func RunGet(cmd *cobra.Command, args []string) error {
 	logger := logs.CreateLogger("cmd.get")
 	context := cmd.Context()
-	repository, err := git.Create(context, git.CreateOptions{
-		RepositoryDir:    repoDir,
-		UpdateRepository: updateRepo,
-	})
+	service, release, err := sources.CreateService(context, sourceOptions)
 	if err != nil {
 		return err
 	}
-
-	service, err := gitignore.Create(repository)
-	if err != nil {
-		return err
+	defer func() { release() }()
+
+	names := splitNames(args)
+	files, err := getFiles(context, service, names)
+	if errors.Is(err, gitignore.ErrUnknownRevision) {
+		// Shallow clones may not contain the revision, so fetch their
+		// complete history and look for the files again. Deepening replaces
+		// the repositories, which must not be read locked meanwhile.
+		release()
+		deepened, deepenErr := sources.Deepen(context, sourceOptions)
+		if deepenErr != nil {
+			logger.Warnf("failed to fetch the complete history: %v", deepenErr)
+		}
+		if deepened {
+			service, release, err = sources.CreateService(context, sourceOptions)
+			if err != nil {
+				return err
+			}
+			files, err = getFiles(context, service, names)
+		}
+	}
+	service.ReportPinned(os.Stderr)
+	if errors.Is(err, gitignore.ErrNotFound) {
+		return nil
 	}
-
-	fileName := args[0]
-	file, err := service.Get(fileName)
 	if err != nil {
-		if errors.Is(err, gitignore.ErrNotFound) {
-			logger.Errorf("no match found for %q", fileName)
-			return nil
-		}
-
 		return err
 	}
 
-	logger.Infof("selected %q", file.Name)
-
 	workingDir, err := os.Getwd()
 	if err != nil {
 		logger.Errorf("failed to determine working directory: %v", err)
@@ -90,22 +89,66 @@ func RunGet(cmd *cobra.Command, args []string) error {
 	}
 	workingDirFs := osfs.New(workingDir)
 
-	if appendToFile {
-		logger.Infof("appending contents to %q", file.Name)
-		err = service.Append(file, workingDirFs)
+	for index, file := range files {
+		// Only the first file overwrites .gitignore, the others are added
+		// after it
+		if appendToFile || index > 0 {
+			logger.Infof("appending contents to %q", file.Name)
+			err = service.Append(context, file, workingDirFs)
+			if err != nil {
+				return err
+			}
+			logger.Info("appended successfully")
+			continue
+		}
+
+		logger.Infof("overwriting .gitignore")
+		err = service.Write(context, file, workingDirFs)
 		if err != nil {
 			return err
 		}
-		logger.Info("appended successfully")
-		return nil
+		logger.Infof(".gitignore written successfully")
 	}
+	sources.RefreshOrWarn(context, sourceOptions)
 
-	logger.Infof("overwriting .gitignore")
-	err = service.Write(file, workingDirFs)
-	if err != nil {
-		return err
+	return nil
+}
+
+// getFiles returns the files matching [names] in order. Names without a
+// match are logged and reported with gitignore.ErrNotFound.
+func getFiles(
+	ctx context.Context,
+	service gitignore.GitIgnoreService,
+	names []string,
+) ([]gitignore.GitIgnoreFile, error) {
+	logger := logs.CreateLogger("cmd.get")
+
+	files := []gitignore.GitIgnoreFile{}
+	for _, fileName := range names {
+		file, err := service.Get(ctx, fileName)
+		if err != nil {
+			if errors.Is(err, gitignore.ErrNotFound) {
+				logger.Errorf("no match found for %q", fileName)
+			}
+			return nil, err
+		}
+
+		logger.Infof("selected %s", sourceOptions.DisplayLabel(file))
+		files = append(files, file)
 	}
-	logger.Infof(".gitignore written successfully")
+	return files, nil
+}
 
-	return nil
+// splitNames splits comma separated lists of names in [args], such as
+// "go,node", into separate names
+func splitNames(args []string) []string {
+	names := []string{}
+	for _, arg := range args {
+		for _, name := range strings.Split(arg, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
 }
@@ -0,0 +1,120 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/internal/sources"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/haroldadmin/getignore/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sourceOptions sources.Options
+	long          bool
+)
+
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available .gitignore files",
+	Long: `The list command prints the name of every available .gitignore file,
+along with its description taken from the comments it starts with.
+
+Templates served over HTTP are listed without descriptions, so that
+they are not all downloaded.
+
+With --long, the size of each template and the date, author and hash of
+the last commit which changed it are printed too, for git sources.`,
+	Args: cobra.NoArgs,
+	RunE: List,
+}
+
+func init() {
+	ListCmd.Flags().BoolVarP(
+		&long,
+		"long",
+		"l",
+		false,
+		"Print the size and the last commit of each template",
+	)
+
+	sources.AddFlags(ListCmd, &sourceOptions)
+}
+
+func List(cmd *cobra.Command, args []string) error {
+	logger := logs.CreateLogger("cmd.list")
+	context := cmd.Context()
+	service, release, err := sources.CreateService(context, sourceOptions)
+	if err != nil {
+		return err
+	}
+	defer release()
+	service.ReportPinned(os.Stderr)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, file := range service.GetAll() {
+		described, err := service.Describe(context, file)
+		if err != nil {
+			logger.Warnf("failed to describe %s: %v", file.QualifiedName(), err)
+		}
+		if !long {
+			fmt.Fprintf(writer, "%s\t%s\n", sourceOptions.DisplayLabel(described), sources.Summary(described))
+			continue
+		}
+
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\n",
+			sourceOptions.DisplayLabel(described),
+			describeMetadata(context, service, file),
+			sources.Summary(described),
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	sources.RefreshOrWarn(context, sourceOptions)
+	return nil
+}
+
+// describeMetadata returns the size of [file] and the date, author and
+// abbreviated hash of the last commit which changed it, separated by tabs.
+// Files without metadata are shown with placeholders, as are the author and
+// hash of files last changed before the start of a shallow clone.
+func describeMetadata(
+	ctx context.Context,
+	service gitignore.GitIgnoreService,
+	file gitignore.GitIgnoreFile,
+) string {
+	logger := logs.CreateLogger("cmd.list")
+
+	metadata, err := service.Metadata(ctx, file)
+	if err != nil {
+		if !errors.Is(err, gitignore.ErrNoHistory) {
+			logger.Warnf("failed to read metadata of %s: %v", file.QualifiedName(), err)
+		}
+		return "-\t-\t-\t-"
+	}
+	if metadata.Truncated {
+		// Only an upper bound of the date of the last change is known
+		return fmt.Sprintf(
+			"%s\tby %s\t-\t-",
+			utils.FormatBytes(metadata.Size),
+			metadata.Date.Format(utils.DateFormat),
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s\t%s\t%s\t%s",
+		utils.FormatBytes(metadata.Size),
+		metadata.Date.Format(utils.DateFormat),
+		metadata.Author,
+		utils.ShortHash(metadata.Commit),
+	)
+}
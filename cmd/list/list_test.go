@@ -0,0 +1,15 @@
+package list_test
+
+import (
+	"testing"
+
+	"github.com/haroldadmin/getignore/cmd/list"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	t.Run("it should have a usage line", func(t *testing.T) {
+		usage := list.ListCmd.Use
+		assert.NotEmpty(t, usage)
+	})
+}
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/haroldadmin/getignore/cmd/cache"
 	"github.com/haroldadmin/getignore/cmd/get"
+	"github.com/haroldadmin/getignore/cmd/list"
 	"github.com/haroldadmin/getignore/cmd/search"
+	"github.com/haroldadmin/getignore/cmd/show"
 	"github.com/haroldadmin/getignore/internal/logs"
 	"github.com/spf13/cobra"
 )
@@ -25,8 +32,19 @@ See available commands and usage instructions using the --help flag.`,
 	},
 }
 
+// Execute runs the command line. The context of commands is cancelled on
+// the first interrupt so that they can stop cleanly, and a second interrupt
+// exits right away.
 func Execute() {
-	cobra.CheckErr(RootCmd.Execute())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := RootCmd.ExecuteContext(ctx)
+	stop()
+	cobra.CheckErr(err)
 }
 
 func init() {
@@ -48,4 +66,7 @@ func init() {
 
 	RootCmd.AddCommand(get.GetCmd)
 	RootCmd.AddCommand(search.SearchCmd)
+	RootCmd.AddCommand(list.ListCmd)
+	RootCmd.AddCommand(show.ShowCmd)
+	RootCmd.AddCommand(cache.CacheCmd)
 }
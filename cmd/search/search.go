@@ -8,7 +8,7 @@ import (
 
 	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/haroldadmin/getignore/internal/logs"
-	"github.com/haroldadmin/getignore/pkg/git"
+	"github.com/haroldadmin/getignore/internal/sources"
 	"github.com/haroldadmin/getignore/pkg/gitignore"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -27,9 +27,8 @@ file you're looking for.`,
 }
 
 var (
-	repoDir      string
-	updateRepo   bool
-	appendToFile bool
+	sourceOptions sources.Options
+	appendToFile  bool
 )
 
 func init() {
@@ -42,45 +41,25 @@ func init() {
 Creates a new .gitignore file if it doesn't exist.`,
 	)
 
-	SearchCmd.Flags().StringVar(
-		&repoDir,
-		"repo-dir",
-		git.DefaultRepoDir(),
-		"Set custom directory for gitignore repository",
-	)
-
-	SearchCmd.Flags().BoolVar(
-		&updateRepo,
-		"update-repo",
-		true,
-		"Update the gitignore repository with upstream changes",
-	)
+	sources.AddFlags(SearchCmd, &sourceOptions)
 }
 
 func Search(cmd *cobra.Command, args []string) error {
 	logger := logs.CreateLogger("cmd.search")
 	context := cmd.Context()
-	options := git.CreateOptions{
-		RepositoryDir:    repoDir,
-		UpdateRepository: updateRepo,
-	}
-
-	repository, err := git.Create(context, options)
-	if err != nil {
-		return err
-	}
-
-	service, err := gitignore.Create(repository)
+	service, release, err := sources.CreateService(context, sourceOptions)
 	if err != nil {
 		return err
 	}
+	defer release()
+	service.ReportPinned(os.Stderr)
 
 	selectedFile, err := promptForQuery(context, service)
 	if err != nil {
 		return err
 	}
 
-	logger.Infof("selected %s", selectedFile.Name)
+	logger.Infof("selected %s", sourceOptions.DisplayLabel(selectedFile))
 	workingDir, err := os.Getwd()
 	if err != nil {
 		logger.Errorf("failed to determine working directory: %v", err)
@@ -90,20 +69,22 @@ func Search(cmd *cobra.Command, args []string) error {
 
 	if appendToFile {
 		logger.Infof("appending contents to %q", selectedFile.Name)
-		err = service.Append(selectedFile, workingDirFs)
+		err = service.Append(context, selectedFile, workingDirFs)
 		if err != nil {
 			return err
 		}
 		logger.Info("appended successfully")
+		sources.RefreshOrWarn(context, sourceOptions)
 		return nil
 	}
 
 	logger.Infof("overwriting .gitignore")
-	err = service.Write(selectedFile, workingDirFs)
+	err = service.Write(context, selectedFile, workingDirFs)
 	if err != nil {
 		return err
 	}
 	logger.Infof(".gitignore written successfully")
+	sources.RefreshOrWarn(context, sourceOptions)
 
 	return nil
 }
@@ -112,6 +93,7 @@ func promptForQuery(
 	ctx context.Context,
 	service gitignore.GitIgnoreService,
 ) (gitignore.GitIgnoreFile, error) {
+	logger := logs.CreateLogger("cmd.search")
 	var selectedFile gitignore.GitIgnoreFile
 	for ctx.Err() == nil {
 		searchPrompt := promptui.Prompt{Label: "Search"}
@@ -130,8 +112,18 @@ func promptForQuery(
 		}
 
 		options := make([]string, 0, len(results))
-		for _, result := range results {
-			options = append(options, result.Name)
+		for index, result := range results {
+			described, err := service.Describe(ctx, result)
+			if err != nil {
+				logger.Debugf("failed to describe %s: %v", result.QualifiedName(), err)
+			}
+			results[index] = described
+
+			option := sourceOptions.DisplayLabel(described)
+			if summary := sources.Summary(described); summary != "" {
+				option += " - " + summary
+			}
+			options = append(options, option)
 		}
 		options = append(options, "search again")
 
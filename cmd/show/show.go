@@ -0,0 +1,93 @@
+package show
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/internal/sources"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/haroldadmin/getignore/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var sourceOptions sources.Options
+
+var ShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show the details of a .gitignore file",
+	Long: `The show command prints the details of the .gitignore file with the
+given name: its source, description and links, and for git sources its
+blob hash, size and the last commit which changed it.
+
+Use this command to check how fresh a template is before adopting it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: Show,
+}
+
+func init() {
+	sources.AddFlags(ShowCmd, &sourceOptions)
+}
+
+func Show(cmd *cobra.Command, args []string) error {
+	logger := logs.CreateLogger("cmd.show")
+	context := cmd.Context()
+	service, release, err := sources.CreateService(context, sourceOptions)
+	if err != nil {
+		return err
+	}
+	defer release()
+	service.ReportPinned(os.Stderr)
+
+	file, err := service.Get(context, args[0])
+	if err != nil {
+		return fmt.Errorf("no match found for %q: %w", args[0], err)
+	}
+
+	described, err := service.Describe(context, file)
+	if err != nil {
+		logger.Warnf("failed to describe %s: %v", file.QualifiedName(), err)
+	}
+
+	fmt.Println(sourceOptions.DisplayName(described))
+	fmt.Printf("  Source:      %s\n", described.Source)
+	fmt.Printf("  Path:        %s\n", described.Path)
+	if described.AliasOf != "" {
+		fmt.Printf("  Alias of:    %s\n", described.AliasOf)
+	}
+	if described.Description != "" {
+		fmt.Printf("  Description: %s\n", described.Description)
+	}
+	for index, link := range described.Links {
+		if index == 0 {
+			fmt.Printf("  Links:       %s\n", link)
+		} else {
+			fmt.Printf("               %s\n", link)
+		}
+	}
+
+	metadata, err := service.Metadata(context, file)
+	if errors.Is(err, gitignore.ErrNoHistory) {
+		logger.Infof("no metadata for %s: %v", file.QualifiedName(), err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Blob:        %s\n", metadata.Hash)
+	fmt.Printf("  Size:        %s\n", utils.FormatBytes(metadata.Size))
+	if metadata.Truncated {
+		// The last change is older than the shallow clone, so only an upper
+		// bound of its date is known
+		fmt.Printf("  Last commit: unknown (shallow history, oldest available is %s)\n", metadata.Commit)
+		fmt.Printf("  Date:        by %s (shallow history)\n", metadata.Date.Format(utils.DateFormat))
+		fmt.Println("  Author:      unknown (shallow history)")
+		return nil
+	}
+	fmt.Printf("  Last commit: %s\n", metadata.Commit)
+	fmt.Printf("  Date:        %s\n", metadata.Date.Format(utils.DateFormat))
+	fmt.Printf("  Author:      %s\n", metadata.Author)
+	return nil
+}
@@ -0,0 +1,15 @@
+package show_test
+
+import (
+	"testing"
+
+	"github.com/haroldadmin/getignore/cmd/show"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShow(t *testing.T) {
+	t.Run("it should have a usage line", func(t *testing.T) {
+		usage := show.ShowCmd.Use
+		assert.NotEmpty(t, usage)
+	})
+}
@@ -31,7 +31,7 @@ func CreateLogger(name string) *log.Entry {
 }
 
 func setupLogLevel(verbose, veryVerbose bool) {
-	logLevel = log.ErrorLevel
+	logLevel = log.WarnLevel
 	if verbose {
 		fmt.Println("Setting info level")
 		logLevel = log.InfoLevel
@@ -0,0 +1,127 @@
+package sources
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/haroldadmin/getignore/pkg/git"
+	"github.com/haroldadmin/getignore/pkg/utils"
+)
+
+// progressRedrawInterval limits how often the progress line is redrawn on
+// terminals
+const progressRedrawInterval = 100 * time.Millisecond
+
+// progressLogInterval is the time between progress lines when the output is
+// not a terminal, such as in CI logs
+const progressLogInterval = 5 * time.Second
+
+// progressReporter prints the progress of cloning or updating a source. On
+// terminals it redraws a single line, otherwise it prints a line every
+// progressLogInterval and once the transfer is done.
+type progressReporter struct {
+	name string
+	out  io.Writer
+	tty  bool
+
+	mutex     sync.Mutex
+	started   time.Time
+	lastPrint time.Time
+	lastPhase git.ProgressPhase
+	drawn     bool
+}
+
+// TrackProgress prints the progress of cloning or updating the source
+// [name] with [createOptions] to stderr. The returned function must be called
+// once the clone or update has returned.
+func TrackProgress(name string, createOptions *git.CreateOptions) func() {
+	reporter := newProgressReporter(name, os.Stderr)
+	createOptions.Progress = reporter.report
+	return reporter.finish
+}
+
+func newProgressReporter(name string, out *os.File) *progressReporter {
+	return &progressReporter{
+		name:    name,
+		out:     out,
+		tty:     isTerminal(out),
+		started: time.Now(),
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (r *progressReporter) report(event git.ProgressEvent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	phaseChanged := event.Phase != r.lastPhase
+	r.lastPhase = event.Phase
+
+	if r.tty {
+		if !event.Done && !phaseChanged && now.Sub(r.lastPrint) < progressRedrawInterval {
+			return
+		}
+		fmt.Fprintf(r.out, "\r\033[K%s", describeProgress(r.name, event))
+		r.drawn = true
+		r.lastPrint = now
+		return
+	}
+
+	last := r.lastPrint
+	if last.IsZero() {
+		last = r.started
+	}
+	finished := event.Done && event.Phase == git.PhaseReceiving
+	if !finished && now.Sub(last) < progressLogInterval {
+		return
+	}
+	fmt.Fprintln(r.out, describeProgress(r.name, event))
+	r.lastPrint = now
+}
+
+// finish ends the progress line on terminals. It is called once the clone
+// or update has returned.
+func (r *progressReporter) finish() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.drawn {
+		fmt.Fprintln(r.out)
+		r.drawn = false
+	}
+}
+
+// describeProgress formats [event] of the source [name], such as
+// "github: receiving objects 1234, 1.2 MiB, done"
+func describeProgress(name string, event git.ProgressEvent) string {
+	description := fmt.Sprintf("%s: %s objects", name, event.Phase)
+
+	switch {
+	case event.Total > 0 && event.Current > 0:
+		description += fmt.Sprintf(" %d%% (%d/%d)", event.Current*100/event.Total, event.Current, event.Total)
+	case event.Total > 0:
+		description += fmt.Sprintf(" (%d)", event.Total)
+	case event.Current > 0:
+		description += fmt.Sprintf(" %d", event.Current)
+	}
+
+	if event.Bytes > 0 {
+		description += ", " + utils.FormatBytes(event.Bytes)
+	}
+	if event.Done {
+		description += ", done"
+	}
+
+	return description
+}
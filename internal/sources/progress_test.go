@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/haroldadmin/getignore/pkg/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeProgress(t *testing.T) {
+	t.Run("it should describe remote phases", func(t *testing.T) {
+		description := describeProgress("acme", git.ProgressEvent{
+			Phase:   git.PhaseCompressing,
+			Current: 9,
+			Total:   20,
+		})
+		assert.Equal(t, "acme: compressing objects 45% (9/20)", description)
+	})
+
+	t.Run("it should describe received bytes", func(t *testing.T) {
+		description := describeProgress("acme", git.ProgressEvent{
+			Phase:   git.PhaseReceiving,
+			Current: 20,
+			Total:   20,
+			Bytes:   3 * 1024 * 1024 / 2,
+			Done:    true,
+		})
+		assert.Equal(t, "acme: receiving objects 100% (20/20), 1.5 MiB, done", description)
+	})
+}
+
+func TestProgressReporter(t *testing.T) {
+	t.Run("it should only log the finished transfer when not on a terminal", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		reporter := &progressReporter{name: "acme", out: out, started: time.Now()}
+
+		reporter.report(git.ProgressEvent{Phase: git.PhaseCounting, Current: 1, Total: 2})
+		reporter.report(git.ProgressEvent{Phase: git.PhaseReceiving, Total: 2, Bytes: 100})
+		reporter.report(git.ProgressEvent{Phase: git.PhaseReceiving, Current: 2, Total: 2, Bytes: 200, Done: true})
+		reporter.finish()
+
+		assert.Equal(t, "acme: receiving objects 100% (2/2), 200 B, done\n", out.String())
+	})
+
+	t.Run("it should redraw a single line on terminals", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		reporter := &progressReporter{name: "acme", out: out, tty: true, started: time.Now()}
+
+		reporter.report(git.ProgressEvent{Phase: git.PhaseCounting, Current: 1, Total: 2})
+		reporter.report(git.ProgressEvent{Phase: git.PhaseReceiving, Current: 2, Total: 2, Done: true})
+		reporter.finish()
+
+		assert.Equal(
+			t,
+			"\r\033[Kacme: counting objects 50% (1/2)\r\033[Kacme: receiving objects 100% (2/2), done\n",
+			out.String(),
+		)
+	})
+}
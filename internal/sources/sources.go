@@ -0,0 +1,865 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/pkg/git"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/haroldadmin/getignore/pkg/network"
+	"github.com/haroldadmin/getignore/pkg/remote"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrInvalidSourceSpec  = errors.New("invalid-source-spec")
+	ErrNoSources          = errors.New("no-sources")
+	ErrInvalidTemplateDir = errors.New("invalid-templates-dir")
+)
+
+// DefaultSourceName is the name of the source backed by --repo-url
+const DefaultSourceName = "github"
+
+// DefaultDirSourceName is the name of a --templates-dir source given without
+// an explicit name
+const DefaultDirSourceName = "local"
+
+// DefaultArchiveSourceName is the name of an --archive source given without
+// an explicit name
+const DefaultArchiveSourceName = "archive"
+
+// DefaultHTTPSourceName is the name of an --http-source given without an
+// explicit name
+const DefaultHTTPSourceName = "http"
+
+// DefaultAPISourceName is the name of an --api-source given without an
+// explicit name
+const DefaultAPISourceName = "gitignoreio"
+
+// Options contains the template source flags shared by all commands
+type Options struct {
+	RepoDir    string
+	RepoURL    string
+	UpdateRepo bool
+	// Ref pins the repository set with RepoURL to a branch, tag or commit
+	Ref string
+	// Depth limits git sources to shallow, single branch clones
+	Depth int
+	// Offline uses cached git sources without touching the network
+	Offline bool
+	// UpdateInterval is the minimum time between updates of git sources
+	UpdateInterval time.Duration
+	// RefreshLater serves cached templates right away and updates git
+	// sources in Refresh instead
+	RefreshLater bool
+	// StaleAfter is the age after which cached templates are reported as old
+	StaleAfter time.Duration
+	// LockTimeout is how long to wait for other processes updating the same
+	// git sources
+	LockTimeout time.Duration
+	// SSHKey is a private key file for SSH sources
+	SSHKey string
+	// Netrc is a netrc file with HTTPS credentials
+	Netrc string
+	// CABundle is a PEM file of certificates trusted by all network
+	// sources, in addition to the system roots
+	CABundle string
+	// Sources are additional "name=url" template repositories, in order of
+	// priority
+	Sources []string
+	// TemplateDirs are "[name=]path" plain directories of templates. They
+	// take priority over all git sources.
+	TemplateDirs []string
+	// Archives are "[name=]path" .zip or .tar.gz archives of template
+	// repositories. They take priority over git sources, after TemplateDirs.
+	Archives []string
+	// HTTPSources are "[name=]url" base URLs of templates served over plain
+	// HTTP with an index.json. They take priority over git sources, after
+	// Archives.
+	HTTPSources []string
+	// APISources are "[name=]url" base URLs of gitignore.io compatible
+	// servers. They have the lowest priority, after all git sources.
+	APISources []string
+	// NoRepo disables the repository set with RepoURL
+	NoRepo bool
+}
+
+// AddFlags registers the template source flags of [cmd] into [options]
+func AddFlags(cmd *cobra.Command, options *Options) {
+	cmd.Flags().StringVar(
+		&options.RepoDir,
+		"repo-dir",
+		git.DefaultRepoDir(),
+		"Set custom directory for gitignore repository",
+	)
+
+	cmd.Flags().StringVar(
+		&options.RepoURL,
+		"repo-url",
+		git.GitIgnoreRepository,
+		"Set custom URL of the gitignore repository to clone",
+	)
+
+	cmd.Flags().BoolVar(
+		&options.UpdateRepo,
+		"update-repo",
+		true,
+		"Update the gitignore repository with upstream changes",
+	)
+
+	cmd.Flags().DurationVar(
+		&options.UpdateInterval,
+		"update-interval",
+		24*time.Hour,
+		`Minimum time between updates of the gitignore repositories.
+Updates on every run when zero.`,
+	)
+
+	cmd.Flags().BoolVar(
+		&options.RefreshLater,
+		"refresh-later",
+		false,
+		`Use cached templates right away, and update the gitignore
+repositories after the command has finished.`,
+	)
+
+	cmd.Flags().DurationVar(
+		&options.StaleAfter,
+		"stale-after",
+		30*24*time.Hour,
+		"Print a notice when cached templates are older than this",
+	)
+
+	cmd.Flags().DurationVar(
+		&options.LockTimeout,
+		"lock-timeout",
+		git.DefaultLockTimeout,
+		`How long to wait for other getignore processes updating the
+same gitignore repository.`,
+	)
+
+	cmd.Flags().StringVar(
+		&options.SSHKey,
+		"ssh-key",
+		"",
+		`Private key file for SSH repository URLs. Uses ssh-agent when empty.
+The passphrase is read from `+git.SSHPassphraseEnv+`.`,
+	)
+
+	cmd.Flags().StringVar(
+		&options.Netrc,
+		"netrc",
+		"",
+		`netrc file with HTTPS credentials. Defaults to $NETRC or ~/.netrc.
+Tokens set in `+git.TokenEnv+` take priority.`,
+	)
+
+	cmd.Flags().StringVar(
+		&options.CABundle,
+		"ca-bundle",
+		"",
+		`PEM file of CA certificates to trust in addition to the system
+roots, such as the root of a TLS intercepting proxy.
+Defaults to $`+network.CABundleEnv+`. Proxies are read from
+HTTPS_PROXY, HTTP_PROXY and NO_PROXY.`,
+	)
+
+	cmd.Flags().BoolVar(
+		&options.Offline,
+		"offline",
+		false,
+		`Use the cached gitignore repositories without touching the network.
+Fails if a repository has not been cloned yet.`,
+	)
+
+	cmd.Flags().StringVar(
+		&options.Ref,
+		"ref",
+		"",
+		`Pin the gitignore repository to a branch, tag or commit.
+Tracks the default branch when empty.`,
+	)
+
+	cmd.Flags().IntVar(
+		&options.Depth,
+		"depth",
+		0,
+		`Clone only the given number of commits of a single branch.
+Clones the complete history when zero.`,
+	)
+
+	cmd.Flags().StringArrayVar(
+		&options.Sources,
+		"source",
+		nil,
+		`Add a named template repository as "name=url[#ref]". Can be repeated.
+Sources listed first take priority over later ones and over the
+repository set with --repo-url, unless it is listed explicitly
+as "`+DefaultSourceName+`=url".`,
+	)
+
+	cmd.Flags().StringArrayVar(
+		&options.TemplateDirs,
+		"templates-dir",
+		nil,
+		`Add a plain directory of templates as "[name=]path". Can be repeated.
+Directories take priority over git repositories. The source name
+defaults to "`+DefaultDirSourceName+`".`,
+	)
+
+	cmd.Flags().StringArrayVar(
+		&options.Archives,
+		"archive",
+		nil,
+		`Add a .zip or .tar.gz archive of a template repository as
+"[name=]path", such as a source archive downloaded from GitHub.
+Can be repeated. Archives take priority over git repositories.
+The source name defaults to "`+DefaultArchiveSourceName+`".`,
+	)
+
+	cmd.Flags().StringArrayVar(
+		&options.HTTPSources,
+		"http-source",
+		nil,
+		`Add templates served over HTTP as "[name=]url", without cloning.
+The server lists templates in url/`+remote.DefaultIndexFile+` and serves
+them at url/<path>. Downloads are cached. Can be repeated.
+The source name defaults to "`+DefaultHTTPSourceName+`".`,
+	)
+
+	cmd.Flags().StringArrayVar(
+		&options.APISources,
+		"api-source",
+		nil,
+		`Add templates of a gitignore.io compatible server as "[name=]url",
+such as `+remote.DefaultAPIBaseURL+`.
+Templates are named after the API, such as "go" or "node".
+Downloads are cached. Can be repeated. API sources have the lowest
+priority. The source name defaults to "`+DefaultAPISourceName+`".`,
+	)
+
+	cmd.Flags().BoolVar(
+		&options.NoRepo,
+		"no-repo",
+		false,
+		"Don't use the gitignore repository set with --repo-url",
+	)
+}
+
+// sourceSpec is a parsed source flag. Specs with a url are git
+// repositories, specs with an archive are archives, specs with a baseURL are
+// served over HTTP, and all others are plain directories of templates.
+type sourceSpec struct {
+	name    string
+	url     string
+	ref     string
+	dir     string
+	archive string
+	baseURL string
+	apiURL  string
+}
+
+func (s sourceSpec) isGit() bool {
+	return s.url != ""
+}
+
+func (s sourceSpec) isArchive() bool {
+	return s.archive != ""
+}
+
+func (s sourceSpec) isHTTP() bool {
+	return s.baseURL != ""
+}
+
+func (s sourceSpec) isAPI() bool {
+	return s.apiURL != ""
+}
+
+func (s sourceSpec) isDir() bool {
+	return !s.isGit() && !s.isArchive() && !s.isHTTP() && !s.isAPI()
+}
+
+func parseSpec(spec string, repoDir string) (sourceSpec, error) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return sourceSpec{}, fmt.Errorf("%w: %q, expected name=url", ErrInvalidSourceSpec, spec)
+	}
+
+	name, url := parts[0], parts[1]
+	if err := validateName(name); err != nil {
+		return sourceSpec{}, err
+	}
+
+	ref := ""
+	if index := strings.LastIndex(url, "#"); index >= 0 {
+		url, ref = url[:index], url[index+1:]
+		if url == "" || ref == "" {
+			return sourceSpec{}, fmt.Errorf("%w: %q, expected name=url#ref", ErrInvalidSourceSpec, spec)
+		}
+	}
+
+	dir := filepath.Join(filepath.Dir(repoDir), "sources", name)
+	if name == DefaultSourceName {
+		dir = repoDir
+	}
+
+	return sourceSpec{name: name, url: url, ref: ref, dir: dir}, nil
+}
+
+func parseDirSpec(spec string) (sourceSpec, error) {
+	name, dir, err := parsePathSpec(spec, DefaultDirSourceName)
+	if err != nil {
+		return sourceSpec{}, err
+	}
+	return sourceSpec{name: name, dir: dir}, nil
+}
+
+func parseArchiveSpec(spec string) (sourceSpec, error) {
+	name, archive, err := parsePathSpec(spec, DefaultArchiveSourceName)
+	if err != nil {
+		return sourceSpec{}, err
+	}
+	return sourceSpec{name: name, archive: archive}, nil
+}
+
+func parseHTTPSpec(spec string, repoDir string) (sourceSpec, error) {
+	name, baseURL, err := parseURLSpec(spec, DefaultHTTPSourceName)
+	if err != nil {
+		return sourceSpec{}, err
+	}
+
+	return sourceSpec{
+		name:    name,
+		baseURL: baseURL,
+		dir:     filepath.Join(filepath.Dir(repoDir), "http", name),
+	}, nil
+}
+
+func parseAPISpec(spec string, repoDir string) (sourceSpec, error) {
+	name, apiURL, err := parseURLSpec(spec, DefaultAPISourceName)
+	if err != nil {
+		return sourceSpec{}, err
+	}
+
+	return sourceSpec{
+		name:   name,
+		apiURL: apiURL,
+		dir:    filepath.Join(filepath.Dir(repoDir), "api", name),
+	}, nil
+}
+
+// parseURLSpec parses a "[name=]url" spec, using [defaultName] when the name
+// is omitted
+func parseURLSpec(spec string, defaultName string) (string, string, error) {
+	name, url := defaultName, spec
+	// URLs contain "=" only in their query, after the scheme
+	if index := strings.Index(spec, "="); index >= 0 && !strings.Contains(spec[:index], "://") {
+		name, url = spec[:index], spec[index+1:]
+	}
+
+	if name == "" || url == "" {
+		return "", "", fmt.Errorf("%w: %q, expected [name=]url", ErrInvalidSourceSpec, spec)
+	}
+	if err := validateName(name); err != nil {
+		return "", "", err
+	}
+
+	return name, url, nil
+}
+
+// parsePathSpec parses a "[name=]path" spec, using [defaultName] when the
+// name is omitted
+func parsePathSpec(spec string, defaultName string) (string, string, error) {
+	name, path := defaultName, spec
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) == 2 {
+		name, path = parts[0], parts[1]
+	}
+
+	if name == "" || path == "" {
+		return "", "", fmt.Errorf("%w: %q, expected [name=]path", ErrInvalidSourceSpec, spec)
+	}
+	if err := validateName(name); err != nil {
+		return "", "", err
+	}
+
+	return name, path, nil
+}
+
+// namePattern matches valid source names. Names are used as directory names
+// of caches and as prefixes of template names, so they are restricted to
+// characters which are safe in both.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+func validateName(name string) error {
+	if name == "." || name == ".." || !namePattern.MatchString(name) {
+		return fmt.Errorf(
+			"%w: source name %q must only contain letters, digits, '.', '_' and '-'",
+			ErrInvalidSourceSpec,
+			name,
+		)
+	}
+	return nil
+}
+
+// specs returns the sources described by [options] in order of priority
+func (options Options) specs() ([]sourceSpec, error) {
+	specs := make(
+		[]sourceSpec,
+		0,
+		len(options.TemplateDirs)+len(options.Archives)+len(options.HTTPSources)+len(options.Sources)+1+len(options.APISources),
+	)
+	for _, rawSpec := range options.TemplateDirs {
+		spec, err := parseDirSpec(rawSpec)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+
+	for _, rawSpec := range options.Archives {
+		spec, err := parseArchiveSpec(rawSpec)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+
+	for _, rawSpec := range options.HTTPSources {
+		spec, err := parseHTTPSpec(rawSpec, options.RepoDir)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+
+	hasDefault := false
+	for _, rawSpec := range options.Sources {
+		spec, err := parseSpec(rawSpec, options.RepoDir)
+		if err != nil {
+			return nil, err
+		}
+		if spec.name == DefaultSourceName {
+			hasDefault = true
+		}
+		specs = append(specs, spec)
+	}
+
+	if !hasDefault && !options.NoRepo {
+		specs = append(specs, sourceSpec{
+			name: DefaultSourceName,
+			url:  options.RepoURL,
+			ref:  options.Ref,
+			dir:  options.RepoDir,
+		})
+	}
+
+	for _, rawSpec := range options.APISources {
+		spec, err := parseAPISpec(rawSpec, options.RepoDir)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// Service is the GitIgnoreService created by CreateService
+type Service struct {
+	gitignore.GitIgnoreService
+	// Pinned are the git sources pinned to a ref, with the commits their
+	// templates are read from
+	Pinned []PinnedSource
+}
+
+// ReportPinned prints the commits the pinned sources of the service are
+// read from to [out], so that the generated .gitignore can be reproduced
+func (s *Service) ReportPinned(out io.Writer) {
+	for _, source := range s.Pinned {
+		fmt.Fprintf(out, "Using %s at %s (commit %s)\n", source.Name, source.Ref, source.Commit)
+	}
+}
+
+// CreateService clones or updates every configured template repository, and
+// creates a GitIgnoreService aggregating all of them. Git repositories are
+// read locked, so that other processes do not garbage collect them while
+// templates are read. The returned function releases the locks, and must be
+// called once the service is no longer used. It is never nil, and is safe to
+// call more than once.
+func CreateService(ctx context.Context, options Options) (*Service, func(), error) {
+	locks := []*git.ReadLock{}
+	release := func() {
+		for _, lock := range locks {
+			lock.Release()
+		}
+	}
+
+	service, err := createService(ctx, options, &locks)
+	if err != nil {
+		release()
+		return nil, release, err
+	}
+	return service, release, nil
+}
+
+// createService creates the service for CreateService, and adds the read
+// locks it takes to [locks]
+func createService(
+	ctx context.Context,
+	options Options,
+	locks *[]*git.ReadLock,
+) (*Service, error) {
+	logger := logs.CreateLogger("sources")
+
+	specs, err := options.specs()
+	if err != nil {
+		return nil, err
+	}
+	if len(specs) == 0 {
+		return nil, ErrNoSources
+	}
+
+	httpClient, err := options.configureNetwork()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]gitignore.Source, 0, len(specs))
+	pinned := []PinnedSource{}
+	for _, spec := range specs {
+		if spec.isDir() {
+			logger.Infof("opening source %q (%s)", spec.name, spec.dir)
+			info, err := os.Stat(spec.dir)
+			if err != nil {
+				return nil, fmt.Errorf("source %q: %w: %v", spec.name, ErrInvalidTemplateDir, err)
+			}
+			if !info.IsDir() {
+				return nil, fmt.Errorf("source %q: %w: %s is not a directory", spec.name, ErrInvalidTemplateDir, spec.dir)
+			}
+			sources = append(sources, gitignore.DirSource(spec.name, spec.dir))
+			continue
+		}
+
+		if spec.isArchive() {
+			logger.Infof("opening source %q (%s)", spec.name, spec.archive)
+			source, err := gitignore.ArchiveSource(spec.name, spec.archive)
+			if err != nil {
+				return nil, fmt.Errorf("source %q: %w", spec.name, err)
+			}
+			sources = append(sources, source)
+			continue
+		}
+
+		if spec.isHTTP() {
+			logger.Infof("opening source %q (%s)", spec.name, spec.baseURL)
+			client, err := remote.New(remote.Options{
+				BaseURL:    spec.baseURL,
+				CacheDir:   spec.dir,
+				Offline:    options.Offline,
+				HTTPClient: httpClient,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("source %q: %w", spec.name, err)
+			}
+			source, err := gitignore.HTTPSource(ctx, spec.name, client)
+			if err != nil {
+				return nil, fmt.Errorf("source %q: %w", spec.name, err)
+			}
+			sources = append(sources, source)
+			continue
+		}
+
+		if spec.isAPI() {
+			logger.Infof("opening source %q (%s)", spec.name, spec.apiURL)
+			client, err := remote.NewAPI(remote.Options{
+				BaseURL:    spec.apiURL,
+				CacheDir:   spec.dir,
+				Offline:    options.Offline,
+				HTTPClient: httpClient,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("source %q: %w", spec.name, err)
+			}
+			source, err := gitignore.APISource(ctx, spec.name, client)
+			if err != nil {
+				return nil, fmt.Errorf("source %q: %w", spec.name, err)
+			}
+			sources = append(sources, source)
+			continue
+		}
+
+		logger.Infof("opening source %q (%s)", spec.name, spec.url)
+		createOptions := options.createOptions(spec)
+		lock, err := git.AcquireReadLock(ctx, createOptions)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", spec.name, err)
+		}
+		*locks = append(*locks, lock)
+
+		finish := TrackProgress(spec.name, &createOptions)
+		repository, err := git.Create(ctx, createOptions)
+		if errors.Is(err, git.ErrCorruptedCache) && !createOptions.Offline {
+			// Create does not clone corrupted repositories again while they
+			// are read locked, by this process too
+			lock.Release()
+			lock, err = repair(ctx, createOptions)
+			if err == nil {
+				*locks = append(*locks, lock)
+				repository, err = git.Create(ctx, createOptions)
+			}
+		}
+		finish()
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", spec.name, err)
+		}
+
+		source := gitignore.RepositorySource(spec.name, repository)
+		source.IndexPath = git.IndexPath(spec.dir)
+		sources = append(sources, source)
+		if spec.ref != "" {
+			// Templates are read from the commit captured by the source,
+			// even if another process moves HEAD meanwhile
+			pinned = append(pinned, PinnedSource{
+				Name:   spec.name,
+				Ref:    spec.ref,
+				Commit: source.Templates.Revision(),
+			})
+		}
+	}
+
+	service, err := gitignore.CreateFromSources(ctx, sources...)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{GitIgnoreService: service, Pinned: pinned}, nil
+}
+
+// repair repairs the corrupted repository of [options] with git.Verify, which
+// waits for other processes to stop reading it, and read locks it again
+func repair(ctx context.Context, options git.CreateOptions) (*git.ReadLock, error) {
+	logger := logs.CreateLogger("sources")
+	logger.Warnf("repairing %s once it is no longer read", options.RepositoryDir)
+
+	if _, err := git.Verify(ctx, options); err != nil {
+		return nil, err
+	}
+	return git.AcquireReadLock(ctx, options)
+}
+
+// Deepen fetches the complete history of the shallow git sources, so that
+// revisions older than their clones can be read. It reports whether any
+// source was deepened, and does nothing in offline mode.
+func Deepen(ctx context.Context, options Options) (bool, error) {
+	if options.Offline {
+		return false, nil
+	}
+
+	logger := logs.CreateLogger("sources")
+	specs, err := options.specs()
+	if err != nil {
+		return false, err
+	}
+	if _, err := options.configureNetwork(); err != nil {
+		return false, err
+	}
+
+	deepened := false
+	for _, spec := range specs {
+		if !spec.isGit() {
+			continue
+		}
+
+		createOptions := options.createOptions(spec)
+		_, status, err := git.Status(ctx, createOptions)
+		if err != nil || !status.Shallow {
+			continue
+		}
+
+		logger.Infof("fetching the complete history of source %q", spec.name)
+		finish := TrackProgress(spec.name, &createOptions)
+		_, err = git.Deepen(ctx, createOptions, 0)
+		finish()
+		if err != nil {
+			return deepened, fmt.Errorf("source %q: %w", spec.name, err)
+		}
+		deepened = true
+	}
+
+	return deepened, nil
+}
+
+// Refresh updates the git sources whose update was deferred by RefreshLater.
+// It does nothing otherwise.
+func Refresh(ctx context.Context, options Options) error {
+	if !options.RefreshLater {
+		return nil
+	}
+
+	logger := logs.CreateLogger("sources")
+	specs, err := options.specs()
+	if err != nil {
+		return err
+	}
+	if _, err := options.configureNetwork(); err != nil {
+		return err
+	}
+
+	for _, spec := range specs {
+		if !spec.isGit() {
+			continue
+		}
+
+		logger.Infof("refreshing source %q", spec.name)
+		createOptions := options.createOptions(spec)
+		finish := TrackProgress(spec.name, &createOptions)
+		err := git.Update(ctx, createOptions)
+		finish()
+		if err != nil {
+			return fmt.Errorf("source %q: %w", spec.name, err)
+		}
+	}
+
+	return nil
+}
+
+// RefreshOrWarn runs Refresh, and only logs its failure as commands have
+// done their work by then
+func RefreshOrWarn(ctx context.Context, options Options) {
+	logger := logs.CreateLogger("cmd.refresh")
+	err := Refresh(ctx, options)
+	if err != nil {
+		logger.Warnf("failed to refresh templates: %v", err)
+	}
+}
+
+func (options Options) createOptions(spec sourceSpec) git.CreateOptions {
+	auth := git.AuthOptionsFromEnv()
+	auth.SSHKeyPath = options.SSHKey
+	auth.NetrcPath = options.Netrc
+	if auth.TokenHost == "" {
+		// The token is meant for the main repository unless scoped otherwise
+		auth.TokenHost = git.URLHost(options.RepoURL)
+	}
+
+	return git.CreateOptions{
+		RepositoryDir:    spec.dir,
+		UpdateRepository: options.UpdateRepo,
+		RemoteURL:        spec.url,
+		Ref:              spec.ref,
+		Depth:            options.Depth,
+		SingleBranch:     options.Depth > 0,
+		Offline:          options.Offline,
+		UpdateInterval:   options.UpdateInterval,
+		DeferUpdate:      options.RefreshLater,
+		StaleAfter:       options.StaleAfter,
+		Auth:             auth,
+		LockTimeout:      options.LockTimeout,
+	}
+}
+
+// configureNetwork creates the HTTP client used by all network sources, and
+// installs it for git sources
+func (options Options) configureNetwork() (*http.Client, error) {
+	networkOptions := network.OptionsFromEnv()
+	if options.CABundle != "" {
+		networkOptions.CABundle = options.CABundle
+	}
+
+	httpClient, err := network.NewHTTPClient(networkOptions, remote.DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clones and fetches can take longer than single downloads, and are
+	// cancelled through their context instead
+	gitClient := *httpClient
+	gitClient.Timeout = 0
+	git.InstallHTTPClient(&gitClient)
+
+	return httpClient, nil
+}
+
+// PinnedSource is a git source pinned to a ref, along with the commit the
+// ref resolved to
+type PinnedSource struct {
+	Name   string
+	Ref    string
+	Commit string
+}
+
+// GitSource is a git template repository configured by Options
+type GitSource struct {
+	Name    string
+	Options git.CreateOptions
+}
+
+// GitSources returns the git sources configured in [options] in order of
+// priority. Plain template directories, archives, HTTP and API sources are
+// left out. The HTTP client for cloning them is installed as a side effect.
+func (options Options) GitSources() ([]GitSource, error) {
+	specs, err := options.specs()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := options.configureNetwork(); err != nil {
+		return nil, err
+	}
+
+	gitSources := make([]GitSource, 0, len(specs))
+	for _, spec := range specs {
+		if !spec.isGit() {
+			continue
+		}
+		gitSources = append(gitSources, GitSource{
+			Name:    spec.name,
+			Options: options.createOptions(spec),
+		})
+	}
+
+	return gitSources, nil
+}
+
+// DisplayName returns the name to show for [file], including its category.
+// Names are qualified with their source only when several sources are
+// configured.
+func (options Options) DisplayName(file gitignore.GitIgnoreFile) string {
+	specs, err := options.specs()
+	if err != nil || len(specs) <= 1 {
+		return file.PathName()
+	}
+	return file.QualifiedName()
+}
+
+// DisplayLabel returns DisplayName of [file], noting the template it is an
+// alias of, such as "Kotlin.gitignore (alias of Java.gitignore)"
+func (options Options) DisplayLabel(file gitignore.GitIgnoreFile) string {
+	label := options.DisplayName(file)
+	if file.AliasOf != "" {
+		label += " (alias of " + file.AliasOf + ")"
+	}
+	return label
+}
+
+// maxSummaryLength limits the length of descriptions shown next to template
+// names
+const maxSummaryLength = 60
+
+// Summary returns the description of [file] shortened to fit next to its
+// name in lists, such as "gitignore template for Drupal 8 projects"
+func Summary(file gitignore.GitIgnoreFile) string {
+	runes := []rune(file.Description)
+	if len(runes) <= maxSummaryLength {
+		return file.Description
+	}
+	return strings.TrimSpace(string(runes[:maxSummaryLength-3])) + "..."
+}
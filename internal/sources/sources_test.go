@@ -0,0 +1,461 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecs(t *testing.T) {
+	repoDir := filepath.Join("cache", "gitignore")
+
+	t.Run("it should add the default source last", func(t *testing.T) {
+		options := Options{
+			RepoDir: repoDir,
+			RepoURL: "https://example.com/default",
+			Sources: []string{"acme=https://example.com/acme"},
+		}
+
+		specs, err := options.specs()
+		assert.NoError(t, err)
+		assert.Len(t, specs, 2)
+		assert.Equal(t, "acme", specs[0].name)
+		assert.Equal(t, filepath.Join("cache", "sources", "acme"), specs[0].dir)
+		assert.Equal(t, DefaultSourceName, specs[1].name)
+		assert.Equal(t, "https://example.com/default", specs[1].url)
+		assert.Equal(t, repoDir, specs[1].dir)
+	})
+
+	t.Run("it should respect an explicit position of the default source", func(t *testing.T) {
+		options := Options{
+			RepoDir: repoDir,
+			Sources: []string{
+				DefaultSourceName + "=https://example.com/default",
+				"acme=https://example.com/acme",
+			},
+		}
+
+		specs, err := options.specs()
+		assert.NoError(t, err)
+		assert.Len(t, specs, 2)
+		assert.Equal(t, DefaultSourceName, specs[0].name)
+		assert.Equal(t, repoDir, specs[0].dir)
+		assert.Equal(t, "acme", specs[1].name)
+	})
+
+	t.Run("it should put template directories first", func(t *testing.T) {
+		options := Options{
+			RepoDir:      repoDir,
+			Sources:      []string{"acme=https://example.com/acme"},
+			TemplateDirs: []string{"/srv/templates", "team=/srv/team"},
+		}
+
+		specs, err := options.specs()
+		assert.NoError(t, err)
+		assert.Len(t, specs, 4)
+		assert.Equal(t, DefaultDirSourceName, specs[0].name)
+		assert.True(t, specs[0].isDir())
+		assert.Equal(t, "/srv/templates", specs[0].dir)
+		assert.Equal(t, "team", specs[1].name)
+		assert.True(t, specs[1].isDir())
+		assert.Equal(t, "acme", specs[2].name)
+		assert.False(t, specs[2].isDir())
+	})
+
+	t.Run("it should put archives after template directories", func(t *testing.T) {
+		options := Options{
+			RepoDir:      repoDir,
+			TemplateDirs: []string{"/srv/templates"},
+			Archives:     []string{"gitignore-main.zip", "team=/srv/team.tar.gz"},
+			NoRepo:       true,
+		}
+
+		specs, err := options.specs()
+		assert.NoError(t, err)
+		assert.Len(t, specs, 3)
+		assert.True(t, specs[0].isDir())
+		assert.Equal(t, DefaultArchiveSourceName, specs[1].name)
+		assert.True(t, specs[1].isArchive())
+		assert.Equal(t, "gitignore-main.zip", specs[1].archive)
+		assert.Equal(t, "team", specs[2].name)
+		assert.Equal(t, "/srv/team.tar.gz", specs[2].archive)
+		assert.False(t, specs[2].isDir())
+	})
+
+	t.Run("it should put HTTP sources after archives", func(t *testing.T) {
+		options := Options{
+			RepoDir:     repoDir,
+			Archives:    []string{"gitignore-main.zip"},
+			HTTPSources: []string{"https://example.com/gitignore?a=b", "team=http://team.example.com"},
+			NoRepo:      true,
+		}
+
+		specs, err := options.specs()
+		assert.NoError(t, err)
+		assert.Len(t, specs, 3)
+		assert.True(t, specs[0].isArchive())
+		assert.Equal(t, DefaultHTTPSourceName, specs[1].name)
+		assert.True(t, specs[1].isHTTP())
+		assert.Equal(t, "https://example.com/gitignore?a=b", specs[1].baseURL)
+		assert.Equal(t, filepath.Join("cache", "http", DefaultHTTPSourceName), specs[1].dir)
+		assert.Equal(t, "team", specs[2].name)
+		assert.Equal(t, "http://team.example.com", specs[2].baseURL)
+		assert.False(t, specs[2].isDir())
+	})
+
+	t.Run("it should put API sources after git sources", func(t *testing.T) {
+		options := Options{
+			RepoDir:    repoDir,
+			RepoURL:    "https://github.com/github/gitignore",
+			APISources: []string{"https://www.toptal.com/developers/gitignore", "self=http://gitignore.example.com"},
+		}
+
+		specs, err := options.specs()
+		assert.NoError(t, err)
+		assert.Len(t, specs, 3)
+		assert.True(t, specs[0].isGit())
+		assert.Equal(t, DefaultAPISourceName, specs[1].name)
+		assert.True(t, specs[1].isAPI())
+		assert.Equal(t, filepath.Join("cache", "api", DefaultAPISourceName), specs[1].dir)
+		assert.Equal(t, "self", specs[2].name)
+		assert.Equal(t, "http://gitignore.example.com", specs[2].apiURL)
+		assert.False(t, specs[2].isDir())
+	})
+
+	t.Run("it should skip the default source with NoRepo", func(t *testing.T) {
+		options := Options{
+			RepoDir:      repoDir,
+			TemplateDirs: []string{"/srv/templates"},
+			NoRepo:       true,
+		}
+
+		specs, err := options.specs()
+		assert.NoError(t, err)
+		assert.Len(t, specs, 1)
+		assert.True(t, specs[0].isDir())
+	})
+
+	t.Run("it should parse pinned refs of sources", func(t *testing.T) {
+		options := Options{
+			RepoDir: repoDir,
+			Ref:     "main",
+			Sources: []string{"acme=https://example.com/acme#v1.2"},
+		}
+
+		specs, err := options.specs()
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/acme", specs[0].url)
+		assert.Equal(t, "v1.2", specs[0].ref)
+		assert.Equal(t, "main", specs[1].ref)
+	})
+
+	t.Run("it should reject malformed sources", func(t *testing.T) {
+		for _, spec := range []string{"acme", "=https://example.com", "acme=", "a:b=https://example.com", "acme=https://example.com#"} {
+			_, err := Options{RepoDir: repoDir, Sources: []string{spec}}.specs()
+			assert.True(t, errors.Is(err, ErrInvalidSourceSpec), spec)
+		}
+	})
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"acme", true},
+		{"acme-templates_v1.2", true},
+		{"Acme", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"a:b", false},
+		{"a/b", false},
+		{"a\\b", false},
+		{"../acme", false},
+		{"a b", false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("it should validate %q", test.name), func(t *testing.T) {
+			err := validateName(test.name)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidSourceSpec))
+			}
+		})
+	}
+}
+
+func TestCreateService(t *testing.T) {
+	t.Run("it should return an error when no sources are configured", func(t *testing.T) {
+		_, _, err := CreateService(context.Background(), Options{NoRepo: true})
+		assert.True(t, errors.Is(err, ErrNoSources))
+	})
+
+	t.Run("it should return an error for a missing templates directory", func(t *testing.T) {
+		_, _, err := CreateService(context.Background(), Options{
+			NoRepo:       true,
+			TemplateDirs: []string{filepath.Join(t.TempDir(), "missing")},
+		})
+		assert.True(t, errors.Is(err, ErrInvalidTemplateDir))
+	})
+
+	t.Run("it should read templates from a plain directory", func(t *testing.T) {
+		dir := t.TempDir()
+		err := ioutil.WriteFile(filepath.Join(dir, "Go.gitignore"), []byte("bin/\n"), 0644)
+		assert.NoError(t, err)
+
+		service, release, err := CreateService(context.Background(), Options{
+			NoRepo:       true,
+			TemplateDirs: []string{dir},
+		})
+		assert.NoError(t, err)
+		defer release()
+
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultDirSourceName, file.Source)
+	})
+
+	t.Run("it should read templates served over HTTP", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/templates/index.json":
+				w.Write([]byte(`["Go.gitignore"]`))
+			case "/templates/Go.gitignore":
+				w.Write([]byte("bin/\n"))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		service, release, err := CreateService(context.Background(), Options{
+			RepoDir:     filepath.Join(t.TempDir(), "gitignore"),
+			NoRepo:      true,
+			HTTPSources: []string{server.URL + "/templates"},
+		})
+		assert.NoError(t, err)
+		defer release()
+
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultHTTPSourceName, file.Source)
+	})
+
+	t.Run("it should read templates from a gitignore.io compatible server", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/list":
+				w.Write([]byte("go,node\n"))
+			case "/api/go":
+				w.Write([]byte("bin/\n"))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		service, release, err := CreateService(context.Background(), Options{
+			RepoDir:    filepath.Join(t.TempDir(), "gitignore"),
+			NoRepo:     true,
+			APISources: []string{server.URL},
+		})
+		assert.NoError(t, err)
+		defer release()
+
+		file, err := service.Get(context.Background(), "go")
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultAPISourceName, file.Source)
+		assert.Equal(t, "go.gitignore", file.Name)
+	})
+}
+
+func TestDeepen(t *testing.T) {
+	t.Run("it should fetch the complete history of shallow sources", func(t *testing.T) {
+		remoteURL, first := testRemote(t)
+		name := "Go.gitignore@" + first[:8]
+		options := Options{
+			RepoDir:    filepath.Join(t.TempDir(), "gitignore"),
+			RepoURL:    remoteURL,
+			UpdateRepo: true,
+			Depth:      1,
+		}
+
+		service, release, err := CreateService(context.Background(), options)
+		assert.NoError(t, err)
+		_, err = service.Get(context.Background(), name)
+		assert.True(t, errors.Is(err, gitignore.ErrUnknownRevision))
+		release()
+
+		deepened, err := Deepen(context.Background(), options)
+		assert.NoError(t, err)
+		assert.True(t, deepened)
+
+		service, release, err = CreateService(context.Background(), options)
+		assert.NoError(t, err)
+		_, err = service.Get(context.Background(), name)
+		assert.NoError(t, err)
+		release()
+
+		deepened, err = Deepen(context.Background(), options)
+		assert.NoError(t, err)
+		assert.False(t, deepened)
+	})
+}
+
+func TestRepair(t *testing.T) {
+	t.Run("it should repair corrupted sources once they are not read", func(t *testing.T) {
+		remoteURL, _ := testRemote(t)
+		options := Options{
+			RepoDir: filepath.Join(t.TempDir(), "gitignore"),
+			RepoURL: remoteURL,
+		}
+		_, release, err := CreateService(context.Background(), options)
+		assert.NoError(t, err)
+		release()
+		assert.NoError(t, os.RemoveAll(filepath.Join(options.RepoDir, ".git", "objects")))
+
+		service, release, err := CreateService(context.Background(), options)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer release()
+		_, err = service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+	})
+}
+
+func TestPinnedSources(t *testing.T) {
+	t.Run("it should report the commits of pinned sources", func(t *testing.T) {
+		remoteURL, first := testRemote(t)
+		options := Options{
+			RepoDir: filepath.Join(t.TempDir(), "gitignore"),
+			RepoURL: remoteURL,
+			Ref:     first[:8],
+		}
+		service, release, err := CreateService(context.Background(), options)
+		assert.NoError(t, err)
+		defer release()
+
+		assert.Equal(t, []PinnedSource{{Name: DefaultSourceName, Ref: first[:8], Commit: first}}, service.Pinned)
+
+		var out strings.Builder
+		service.ReportPinned(&out)
+		assert.Equal(t, fmt.Sprintf("Using %s at %s (commit %s)\n", DefaultSourceName, first[:8], first), out.String())
+	})
+
+	t.Run("it should leave out sources which are not pinned", func(t *testing.T) {
+		remoteURL, _ := testRemote(t)
+		options := Options{
+			RepoDir: filepath.Join(t.TempDir(), "gitignore"),
+			RepoURL: remoteURL,
+		}
+		service, release, err := CreateService(context.Background(), options)
+		assert.NoError(t, err)
+		defer release()
+
+		assert.Empty(t, service.Pinned)
+	})
+}
+
+func TestDisplayName(t *testing.T) {
+	file := gitignore.GitIgnoreFile{Name: "Go.gitignore", Source: "acme"}
+
+	t.Run("it should not qualify names with a single source", func(t *testing.T) {
+		assert.Equal(t, "Go.gitignore", Options{}.DisplayName(file))
+	})
+
+	t.Run("it should qualify names with several sources", func(t *testing.T) {
+		options := Options{Sources: []string{"acme=https://example.com/acme"}}
+		assert.Equal(t, "acme:Go.gitignore", options.DisplayName(file))
+	})
+
+	t.Run("it should note aliases in labels", func(t *testing.T) {
+		file := gitignore.GitIgnoreFile{Name: "Kotlin.gitignore", AliasOf: "Java.gitignore"}
+		assert.Equal(t, "Kotlin.gitignore (alias of Java.gitignore)", Options{}.DisplayLabel(file))
+	})
+
+	t.Run("it should include the category of the file", func(t *testing.T) {
+		file := gitignore.GitIgnoreFile{Name: "macOS.gitignore", Category: "Global", Source: "acme"}
+		assert.Equal(t, "Global/macOS.gitignore", Options{}.DisplayName(file))
+	})
+}
+
+func TestSummary(t *testing.T) {
+	t.Run("it should keep short descriptions", func(t *testing.T) {
+		file := gitignore.GitIgnoreFile{Description: "For projects using Nanoc"}
+		assert.Equal(t, "For projects using Nanoc", Summary(file))
+	})
+
+	t.Run("it should shorten long descriptions", func(t *testing.T) {
+		file := gitignore.GitIgnoreFile{
+			Description: "Ignore Visual Studio temporary files, build results, and files generated by popular Visual Studio add-ons.",
+		}
+		summary := Summary(file)
+		assert.True(t, len(summary) <= 60)
+		assert.True(t, strings.HasPrefix(summary, "Ignore Visual Studio temporary files"))
+		assert.True(t, strings.HasSuffix(summary, "..."))
+	})
+}
+
+func TestGitSources(t *testing.T) {
+	t.Run("it should leave out template directories and archives", func(t *testing.T) {
+		options := Options{
+			RepoDir:      filepath.Join("cache", "gitignore"),
+			RepoURL:      "https://example.com/default",
+			Sources:      []string{"acme=https://example.com/acme#v1"},
+			TemplateDirs: []string{"templates"},
+			Archives:     []string{"gitignore-main.zip"},
+		}
+
+		gitSources, err := options.GitSources()
+		assert.NoError(t, err)
+		assert.Len(t, gitSources, 2)
+		assert.Equal(t, "acme", gitSources[0].Name)
+		assert.Equal(t, "https://example.com/acme", gitSources[0].Options.RemoteURL)
+		assert.Equal(t, "v1", gitSources[0].Options.Ref)
+		assert.Equal(t, DefaultSourceName, gitSources[1].Name)
+	})
+}
+
+// testRemote creates a remote with two commits, and returns its URL and the
+// hash of the first commit
+func testRemote(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	hashes := []string{}
+	for _, contents := range []string{"v1\n", "v2\n"} {
+		err := ioutil.WriteFile(filepath.Join(dir, "Go.gitignore"), []byte(contents), 0644)
+		assert.NoError(t, err)
+		_, err = worktree.Add("Go.gitignore")
+		assert.NoError(t, err)
+		hash, err := worktree.Commit(contents, &git.CommitOptions{
+			Author: &object.Signature{Name: "getignore", When: time.Now()},
+		})
+		assert.NoError(t, err)
+		hashes = append(hashes, hash.String())
+	}
+
+	return "file://" + filepath.ToSlash(dir), hashes[0]
+}
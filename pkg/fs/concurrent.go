@@ -0,0 +1,217 @@
+package fs
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/haroldadmin/getignore/pkg/utils"
+)
+
+// ReadDirConcurrently lists the files in [filesystem] like
+// ReadDirRecursively, but reads up to options.Workers directories at once.
+// It is meant for large trees, such as monorepos scanned for projects.
+//
+// The files are returned sorted by path, and the errors of a *WalkError are
+// sorted by directory, so that the results do not depend on scheduling.
+// Calls to options.OnEntry never overlap, but their order is not defined.
+func ReadDirConcurrently(
+	ctx context.Context,
+	filesystem billy.Filesystem,
+	options WalkOptions,
+) ([]DiscoveredFile, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	workers := options.Workers
+	if workers <= 0 {
+		// Reading directories mostly waits on the disk, so more
+		// workers than CPUs keep it busy
+		workers = 4 * runtime.NumCPU()
+	}
+
+	walk := &concurrentWalk{
+		filesystem: filesystem,
+		options:    options,
+		skipDirs:   options.skipDirs(),
+		queue:      newDirQueue(),
+		allFiles:   []DiscoveredFile{},
+	}
+	walk.queue.push("/")
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			walk.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(walk.allFiles, func(i, j int) bool {
+		return walk.allFiles[i].Path < walk.allFiles[j].Path
+	})
+
+	if walk.err != nil {
+		return walk.allFiles, walk.err
+	}
+	if len(walk.walkErrors) > 0 {
+		sort.Slice(walk.walkErrors, func(i, j int) bool {
+			return walk.walkErrors[i].Error() < walk.walkErrors[j].Error()
+		})
+		return walk.allFiles, &WalkError{Errors: walk.walkErrors}
+	}
+	return walk.allFiles, nil
+}
+
+// concurrentWalk is the state shared by the workers of ReadDirConcurrently
+type concurrentWalk struct {
+	filesystem billy.Filesystem
+	options    WalkOptions
+	skipDirs   utils.StringSet
+	queue      *dirQueue
+
+	// mutex guards the fields below, and serializes calls to OnEntry
+	mutex      sync.Mutex
+	allFiles   []DiscoveredFile
+	walkErrors []error
+	// err stops the walk, such as a cancelled context or an OnEntry error
+	err error
+}
+
+func (w *concurrentWalk) work(ctx context.Context) {
+	for {
+		dirPath, ok := w.queue.pop()
+		if !ok {
+			return
+		}
+
+		if err := ctx.Err(); err != nil {
+			w.stop(err)
+			w.queue.done()
+			return
+		}
+
+		files, subdirs, err := listDir(w.filesystem, dirPath, w.options, w.skipDirs)
+		if err != nil {
+			w.mutex.Lock()
+			w.walkErrors = append(w.walkErrors, err)
+			w.mutex.Unlock()
+		} else {
+			w.queue.push(subdirs...)
+			w.collect(files)
+		}
+		w.queue.done()
+	}
+}
+
+// collect adds [files] to the results, or passes them to OnEntry
+func (w *concurrentWalk) collect(files []DiscoveredFile) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.queue.isClosed() {
+		return
+	}
+	if w.options.OnEntry == nil {
+		w.allFiles = append(w.allFiles, files...)
+		return
+	}
+
+	for _, file := range files {
+		if err := w.options.OnEntry(file); err != nil {
+			if err != ErrStopWalk {
+				w.err = err
+			}
+			w.queue.close()
+			return
+		}
+	}
+}
+
+// stop ends the walk with [err], unless it has already ended
+func (w *concurrentWalk) stop(err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err == nil {
+		w.err = err
+	}
+	w.queue.close()
+}
+
+// dirQueue holds the directories waiting to be read. Workers block on pop
+// until a directory is available, or until no directory is left and none is
+// being read, which means the walk is complete.
+type dirQueue struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	dirs  []string
+	// pending counts the directories which were pushed but not done
+	pending int
+	closed  bool
+}
+
+func newDirQueue() *dirQueue {
+	queue := &dirQueue{}
+	queue.cond = sync.NewCond(&queue.mutex)
+	return queue
+}
+
+func (q *dirQueue) push(dirs ...string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.dirs = append(q.dirs, dirs...)
+	q.pending += len(dirs)
+	q.cond.Broadcast()
+}
+
+// pop removes the next directory. It returns false once the walk is complete
+// or closed.
+func (q *dirQueue) pop() (string, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for len(q.dirs) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return "", false
+	}
+
+	first := q.dirs[0]
+	q.dirs = q.dirs[1:]
+	return first, true
+}
+
+// done marks a popped directory as read, after its subdirectories are pushed
+func (q *dirQueue) done() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.pending--
+	if q.pending == 0 {
+		q.closed = true
+		q.cond.Broadcast()
+	}
+}
+
+func (q *dirQueue) close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+func (q *dirQueue) isClosed() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.closed
+}
@@ -1,9 +1,13 @@
 package fs
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/haroldadmin/getignore/internal/logs"
@@ -13,9 +17,13 @@ import (
 var defaultSkipDirs []string = []string{".git"}
 
 var (
-	ErrStatFailed = errors.New("stat-failed")
-	ErrNotDir     = errors.New("not-a-directory")
-	ErrReadDir    = errors.New("failed-to-read-dir")
+	ErrStatFailed     = errors.New("stat-failed")
+	ErrNotDir         = errors.New("not-a-directory")
+	ErrReadDir        = errors.New("failed-to-read-dir")
+	ErrInvalidPattern = errors.New("invalid-pattern")
+	// ErrStopWalk is returned by WalkOptions.OnEntry to stop the walk
+	// without an error
+	ErrStopWalk = errors.New("stop-walk")
 )
 
 type DiscoveredFile struct {
@@ -24,68 +32,240 @@ type DiscoveredFile struct {
 	IsSymlink bool
 }
 
+// WalkOptions contains config parameters for ReadDirRecursively and
+// ReadDirConcurrently
+type WalkOptions struct {
+	// SkipDirs are names of directories which are not read, in addition to
+	// ".git"
+	SkipDirs []string
+	// MaxDepth limits how many levels of directories are read, counting the
+	// root as the first level. All levels are read when zero.
+	MaxDepth int
+	// Include are glob patterns such as "*.gitignore" or "Global/*". Only
+	// files whose name or slash separated path matches one of them are
+	// returned. All files are returned when empty.
+	Include []string
+	// Exclude are glob patterns of files and directories which are left out,
+	// matched like Include. Excluded directories are not read.
+	Exclude []string
+	// OnEntry is called with each file as soon as it is found, instead of
+	// collecting all files into the returned slice. Returning an error stops
+	// the walk; ErrStopWalk stops it without an error.
+	OnEntry func(file DiscoveredFile) error
+	// Workers is how many directories ReadDirConcurrently reads at once.
+	// It defaults to four times the number of CPUs when zero.
+	Workers int
+}
+
+// WalkError aggregates the errors of all directories which could not be
+// read. It matches ErrReadDir, and the errors it aggregates, with errors.Is.
+type WalkError struct {
+	Errors []error
+}
+
+func (e *WalkError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for index, err := range e.Errors {
+		messages[index] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *WalkError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// readDirError reports a directory which could not be read. It matches
+// ErrReadDir with errors.Is, and unwraps to the underlying cause.
+type readDirError struct {
+	path string
+	err  error
+}
+
+func (e *readDirError) Error() string {
+	return fmt.Sprintf("%v: %s: %v", ErrReadDir, e.path, e.err)
+}
+
+func (e *readDirError) Is(target error) bool {
+	return target == ErrReadDir
+}
+
+func (e *readDirError) Unwrap() error {
+	return e.err
+}
+
+// ReadDirRecursively lists the files in [filesystem] breadth first. A
+// directory which can not be read does not stop the walk: the files found
+// elsewhere are returned along with a *WalkError listing every failure. The
+// walk stops with the error of [ctx] when it is cancelled.
 func ReadDirRecursively(
+	ctx context.Context,
 	filesystem billy.Filesystem,
-	customSkipDirs ...string,
+	options WalkOptions,
 ) ([]DiscoveredFile, error) {
-	logger := logs.CreateLogger("fs")
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
 	allFiles := []DiscoveredFile{}
+	walkErrors := []error{}
 	queue := utils.NewQueue()
-	skipDirs := utils.NewSet(customSkipDirs...).Add(defaultSkipDirs...)
+	skipDirs := options.skipDirs()
 
-	readDir := func(path string) error {
-		dirName := filepath.Base(path)
-		if skipDirs.Contains(dirName) {
-			logger.Debugf("Skipped: %s", dirName)
-			return nil
+	queue.Add("/")
+	for queue.Length() != 0 {
+		if err := ctx.Err(); err != nil {
+			return allFiles, err
+		}
+
+		first, err := queue.RemoveFirst()
+		if err != nil {
+			break
 		}
 
-		dirEntries, err := filesystem.ReadDir(path)
+		files, subdirs, err := listDir(filesystem, first, options, skipDirs)
 		if err != nil {
-			message := "failed to read directory"
-			logger.Errorf("%s (%q): %v", message, path, err)
-			return ErrReadDir
+			walkErrors = append(walkErrors, err)
+			continue
+		}
+		for _, subdir := range subdirs {
+			queue.Add(subdir)
 		}
 
-		for _, entry := range dirEntries {
-			if entry.IsDir() {
-				logger.Debugf("Dir: %s", entry.Name())
-				queue.Add(filepath.Join(path, entry.Name()))
+		stopped := false
+		for _, file := range files {
+			if options.OnEntry == nil {
+				allFiles = append(allFiles, file)
 				continue
 			}
-
-			if entry.Mode()&os.ModeSymlink == os.ModeSymlink {
-				logger.Debugf("Symlink: %s", entry.Name())
-				discoveredFile := DiscoveredFile{
-					Name:      entry.Name(),
-					Path:      filepath.Join(path, entry.Name()),
-					IsSymlink: true,
+			if err := options.OnEntry(file); err != nil {
+				if err != ErrStopWalk {
+					return allFiles, err
 				}
-				allFiles = append(allFiles, discoveredFile)
-				continue
+				stopped = true
+				break
 			}
+		}
+		if stopped {
+			break
+		}
+	}
 
-			logger.Debugf("File: %s", entry.Name())
-			discoveredFile := DiscoveredFile{
-				Name:      entry.Name(),
-				Path:      filepath.Join(path, entry.Name()),
-				IsSymlink: false,
+	if len(walkErrors) > 0 {
+		return allFiles, &WalkError{Errors: walkErrors}
+	}
+	return allFiles, nil
+}
+
+func (o WalkOptions) validate() error {
+	for _, patterns := range [][]string{o.Include, o.Exclude} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%w: %q", ErrInvalidPattern, pattern)
 			}
-			allFiles = append(allFiles, discoveredFile)
 		}
+	}
+	return nil
+}
+
+func (o WalkOptions) skipDirs() utils.StringSet {
+	return utils.NewSet(o.SkipDirs...).Add(defaultSkipDirs...)
+}
+
+// listDir reads the directory [dirPath], and returns the included files and
+// the subdirectories to read next
+func listDir(
+	filesystem billy.Filesystem,
+	dirPath string,
+	options WalkOptions,
+	skipDirs utils.StringSet,
+) ([]DiscoveredFile, []string, error) {
+	logger := logs.CreateLogger("fs")
 
-		return nil
+	dirName := filepath.Base(dirPath)
+	if skipDirs.Contains(dirName) {
+		logger.Debugf("Skipped: %s", dirName)
+		return nil, nil, nil
 	}
 
-	readDir("/")
+	dirEntries, err := filesystem.ReadDir(dirPath)
+	if err != nil {
+		message := "failed to read directory"
+		logger.Errorf("%s (%q): %v", message, dirPath, err)
+		return nil, nil, &readDirError{path: dirPath, err: err}
+	}
 
-	for queue.Length() != 0 {
-		first, err := queue.RemoveFirst()
-		if err != nil {
-			break
+	files := []DiscoveredFile{}
+	subdirs := []string{}
+	canDescend := options.MaxDepth <= 0 || depth(dirPath) < options.MaxDepth
+	for _, entry := range dirEntries {
+		entryPath := filepath.Join(dirPath, entry.Name())
+		if options.excluded(entryPath) {
+			logger.Debugf("Excluded: %s", entryPath)
+			continue
+		}
+
+		if entry.IsDir() {
+			logger.Debugf("Dir: %s", entry.Name())
+			if canDescend {
+				subdirs = append(subdirs, entryPath)
+			}
+			continue
+		}
+
+		isSymlink := entry.Mode()&os.ModeSymlink == os.ModeSymlink
+		if isSymlink {
+			logger.Debugf("Symlink: %s", entry.Name())
+		} else {
+			logger.Debugf("File: %s", entry.Name())
+		}
+		if !options.included(entryPath) {
+			continue
 		}
-		readDir(first)
+		files = append(files, DiscoveredFile{
+			Name:      entry.Name(),
+			Path:      entryPath,
+			IsSymlink: isSymlink,
+		})
 	}
 
-	return allFiles, nil
+	return files, subdirs, nil
+}
+
+// depth returns the level of the directory [dirPath], where the root is 1
+func depth(dirPath string) int {
+	relative := strings.Trim(filepath.ToSlash(dirPath), "/")
+	if relative == "" {
+		return 1
+	}
+	return strings.Count(relative, "/") + 2
+}
+
+// matches reports whether the name or the slash separated path of
+// [filePath] matches any of [patterns]
+func matches(patterns []string, filePath string) bool {
+	relative := strings.TrimPrefix(filepath.ToSlash(filePath), "/")
+	name := path.Base(relative)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, relative); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (o WalkOptions) included(filePath string) bool {
+	return len(o.Include) == 0 || matches(o.Include, filePath)
+}
+
+func (o WalkOptions) excluded(entryPath string) bool {
+	return len(o.Exclude) > 0 && matches(o.Exclude, entryPath)
 }
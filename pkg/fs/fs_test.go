@@ -1,12 +1,19 @@
 package fs_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/haroldadmin/getignore/pkg/fs"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,7 +29,7 @@ func TestReadDirRecursively(t *testing.T) {
 		defer os.RemoveAll(dir)
 
 		osFs := osfs.New(dir)
-		files, err := fs.ReadDirRecursively(osFs)
+		files, err := fs.ReadDirRecursively(context.Background(), osFs, fs.WalkOptions{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, fileCount, len(files))
@@ -42,7 +49,7 @@ func TestReadDirRecursively(t *testing.T) {
 		}
 
 		osFs := osfs.New(parentDir)
-		files, err := fs.ReadDirRecursively(osFs)
+		files, err := fs.ReadDirRecursively(context.Background(), osFs, fs.WalkOptions{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, nestedDirCount*nestedFileCount, len(files))
@@ -65,13 +72,297 @@ func TestReadDirRecursively(t *testing.T) {
 		}
 
 		osFs := osfs.New(parentDir)
-		files, err := fs.ReadDirRecursively(osFs, skipSet...)
+		files, err := fs.ReadDirRecursively(context.Background(), osFs, fs.WalkOptions{SkipDirs: skipSet})
 
 		assert.NoError(t, err)
 		assert.Empty(t, files)
 	})
 }
 
+// failingFs fails to read the directories in failing
+type failingFs struct {
+	billy.Filesystem
+	failing map[string]bool
+}
+
+func (f *failingFs) ReadDir(path string) ([]os.FileInfo, error) {
+	if f.failing[path] {
+		return nil, os.ErrPermission
+	}
+	return f.Filesystem.ReadDir(path)
+}
+
+// walkTestFs creates "/Go.gitignore", "/README.md" and
+// "/Global/macOS.gitignore" and "/Global/Deep/Linux.gitignore"
+func walkTestFs(t *testing.T) billy.Filesystem {
+	t.Helper()
+
+	memFs := memfs.New()
+	for _, name := range []string{"Go.gitignore", "README.md", "Global/macOS.gitignore", "Global/Deep/Linux.gitignore"} {
+		if err := util.WriteFile(memFs, name, []byte(name), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return memFs
+}
+
+func names(files []fs.DiscoveredFile) []string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWalkOptions(t *testing.T) {
+	t.Run("it should limit the depth of the walk", func(t *testing.T) {
+		files, err := fs.ReadDirRecursively(context.Background(), walkTestFs(t), fs.WalkOptions{MaxDepth: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Go.gitignore", "README.md"}, names(files))
+
+		files, err = fs.ReadDirRecursively(context.Background(), walkTestFs(t), fs.WalkOptions{MaxDepth: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Go.gitignore", "README.md", "macOS.gitignore"}, names(files))
+	})
+
+	t.Run("it should include and exclude files matching globs", func(t *testing.T) {
+		files, err := fs.ReadDirRecursively(context.Background(), walkTestFs(t), fs.WalkOptions{
+			Include: []string{"*.gitignore"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Go.gitignore", "Linux.gitignore", "macOS.gitignore"}, names(files))
+
+		files, err = fs.ReadDirRecursively(context.Background(), walkTestFs(t), fs.WalkOptions{
+			Include: []string{"*.gitignore"},
+			Exclude: []string{"Global/Deep"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Go.gitignore", "macOS.gitignore"}, names(files))
+	})
+
+	t.Run("it should reject invalid globs", func(t *testing.T) {
+		_, err := fs.ReadDirRecursively(context.Background(), walkTestFs(t), fs.WalkOptions{Include: []string{"["}})
+		assert.True(t, errors.Is(err, fs.ErrInvalidPattern))
+	})
+
+	t.Run("it should stream files to the callback", func(t *testing.T) {
+		streamed := []fs.DiscoveredFile{}
+		files, err := fs.ReadDirRecursively(context.Background(), walkTestFs(t), fs.WalkOptions{
+			OnEntry: func(file fs.DiscoveredFile) error {
+				streamed = append(streamed, file)
+				return nil
+			},
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+		assert.Len(t, streamed, 4)
+	})
+
+	t.Run("it should stop the walk when the callback returns an error", func(t *testing.T) {
+		count := 0
+		_, err := fs.ReadDirRecursively(context.Background(), walkTestFs(t), fs.WalkOptions{
+			OnEntry: func(file fs.DiscoveredFile) error {
+				count++
+				return fs.ErrStopWalk
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		failure := errors.New("failure")
+		_, err = fs.ReadDirRecursively(context.Background(), walkTestFs(t), fs.WalkOptions{
+			OnEntry: func(file fs.DiscoveredFile) error {
+				return failure
+			},
+		})
+		assert.Equal(t, failure, err)
+	})
+
+	t.Run("it should stop when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := fs.ReadDirRecursively(ctx, walkTestFs(t), fs.WalkOptions{})
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("it should report every directory which can not be read", func(t *testing.T) {
+		failing := &failingFs{
+			Filesystem: walkTestFs(t),
+			failing: map[string]bool{
+				filepath.Join("/", "Global", "Deep"): true,
+			},
+		}
+		assert.NoError(t, util.WriteFile(failing, "Broken/Old.gitignore", nil, 0644))
+		failing.failing[filepath.Join("/", "Broken")] = true
+
+		files, err := fs.ReadDirRecursively(context.Background(), failing, fs.WalkOptions{})
+		assert.True(t, errors.Is(err, fs.ErrReadDir))
+		assert.True(t, errors.Is(err, os.ErrPermission))
+		assert.Contains(t, err.Error(), "Deep")
+		assert.Contains(t, err.Error(), "Broken")
+
+		var walkErr *fs.WalkError
+		assert.True(t, errors.As(err, &walkErr))
+		assert.Len(t, walkErr.Errors, 2)
+		assert.Equal(t, []string{"Go.gitignore", "README.md", "macOS.gitignore"}, names(files))
+	})
+}
+
+func TestReadDirConcurrently(t *testing.T) {
+	t.Run("it should find the same files as ReadDirRecursively, sorted by path", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTree(t, dir, 3, 4, 5)
+
+		expected, err := fs.ReadDirRecursively(context.Background(), osfs.New(dir), fs.WalkOptions{})
+		assert.NoError(t, err)
+		sort.Slice(expected, func(i, j int) bool { return expected[i].Path < expected[j].Path })
+
+		for _, workers := range []int{0, 1, 4, 64} {
+			files, err := fs.ReadDirConcurrently(context.Background(), osfs.New(dir), fs.WalkOptions{Workers: workers})
+			assert.NoError(t, err)
+			assert.Equal(t, expected, files)
+		}
+	})
+
+	t.Run("it should apply the walk options", func(t *testing.T) {
+		files, err := fs.ReadDirConcurrently(context.Background(), walkTestFs(t), fs.WalkOptions{
+			MaxDepth: 2,
+			Include:  []string{"*.gitignore"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Go.gitignore", "macOS.gitignore"}, names(files))
+
+		_, err = fs.ReadDirConcurrently(context.Background(), walkTestFs(t), fs.WalkOptions{Exclude: []string{"["}})
+		assert.True(t, errors.Is(err, fs.ErrInvalidPattern))
+	})
+
+	t.Run("it should serialize calls to the callback", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTree(t, dir, 1, 8, 8)
+
+		streamed := []fs.DiscoveredFile{}
+		files, err := fs.ReadDirConcurrently(context.Background(), osfs.New(dir), fs.WalkOptions{
+			Workers: 8,
+			OnEntry: func(file fs.DiscoveredFile) error {
+				streamed = append(streamed, file)
+				return nil
+			},
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+		assert.Len(t, streamed, 8+8*8)
+	})
+
+	t.Run("it should stop the walk when the callback returns an error", func(t *testing.T) {
+		count := 0
+		_, err := fs.ReadDirConcurrently(context.Background(), walkTestFs(t), fs.WalkOptions{
+			OnEntry: func(file fs.DiscoveredFile) error {
+				count++
+				return fs.ErrStopWalk
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		failure := errors.New("failure")
+		_, err = fs.ReadDirConcurrently(context.Background(), walkTestFs(t), fs.WalkOptions{
+			OnEntry: func(file fs.DiscoveredFile) error {
+				return failure
+			},
+		})
+		assert.Equal(t, failure, err)
+	})
+
+	t.Run("it should stop when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := fs.ReadDirConcurrently(ctx, walkTestFs(t), fs.WalkOptions{})
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("it should report failing directories in a stable order", func(t *testing.T) {
+		failing := &failingFs{
+			Filesystem: walkTestFs(t),
+			failing: map[string]bool{
+				filepath.Join("/", "Global", "Deep"): true,
+			},
+		}
+		assert.NoError(t, util.WriteFile(failing, "Broken/Old.gitignore", nil, 0644))
+		failing.failing[filepath.Join("/", "Broken")] = true
+
+		files, err := fs.ReadDirConcurrently(context.Background(), failing, fs.WalkOptions{Workers: 4})
+		assert.True(t, errors.Is(err, fs.ErrReadDir))
+		assert.True(t, errors.Is(err, os.ErrPermission))
+
+		var walkErr *fs.WalkError
+		assert.True(t, errors.As(err, &walkErr))
+		if assert.Len(t, walkErr.Errors, 2) {
+			assert.Contains(t, walkErr.Errors[0].Error(), "Broken")
+			assert.Contains(t, walkErr.Errors[1].Error(), "Deep")
+		}
+		assert.Equal(t, []string{"Go.gitignore", "README.md", "macOS.gitignore"}, names(files))
+	})
+}
+
+// benchmarkTree creates a tree of 4 levels with 8 directories and 16 files
+// in each directory, once per benchmark
+func benchmarkTree(b *testing.B) billy.Filesystem {
+	b.Helper()
+	dir := b.TempDir()
+	writeTree(b, dir, 3, 8, 16)
+	return osfs.New(dir)
+}
+
+func BenchmarkReadDirRecursively(b *testing.B) {
+	filesystem := benchmarkTree(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := fs.ReadDirRecursively(context.Background(), filesystem, fs.WalkOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadDirConcurrently(b *testing.B) {
+	filesystem := benchmarkTree(b)
+	for _, workers := range []int{1, 4, 16, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := fs.ReadDirConcurrently(context.Background(), filesystem, fs.WalkOptions{Workers: workers})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// writeTree creates [dirs] directories in [dir], nested [levels] deep, with
+// [files] empty files in every directory
+func writeTree(t testing.TB, dir string, levels, dirs, files int) {
+	t.Helper()
+	for i := 0; i < files; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%d.gitignore", i))
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	if levels == 0 {
+		return
+	}
+
+	for i := 0; i < dirs; i++ {
+		nested := filepath.Join(dir, fmt.Sprintf("dir%d", i))
+		if err := os.Mkdir(nested, os.ModePerm); err != nil {
+			t.Fatalf("failed to create %s: %v", nested, err)
+		}
+		writeTree(t, nested, levels-1, dirs, files)
+	}
+}
+
 func testFile(t *testing.T, dir, fileName string) *os.File {
 	t.Helper()
 	path := filepath.Join(dir, fileName)
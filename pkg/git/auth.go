@@ -0,0 +1,252 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/mitchellh/go-homedir"
+)
+
+var ErrAuthFailed = errors.New("authentication-failed")
+
+// Environment variables read by AuthOptionsFromEnv
+const (
+	TokenEnv         = "GETIGNORE_TOKEN"
+	TokenHostEnv     = "GETIGNORE_TOKEN_HOST"
+	UsernameEnv      = "GETIGNORE_USERNAME"
+	SSHPassphraseEnv = "GETIGNORE_SSH_PASSPHRASE"
+)
+
+// DefaultTokenUsername is sent along with HTTPS tokens when no username is
+// configured. Most git hosts accept any username with a token.
+const DefaultTokenUsername = "getignore"
+
+// AuthOptions contains credentials for private template repositories.
+// Credentials embedded in the repository URL take priority over these.
+type AuthOptions struct {
+	// Username is sent with Token over HTTPS, and used as the SSH user when
+	// the URL does not specify one
+	Username string
+	// Token is an HTTPS access token, sent with basic authentication
+	Token string
+	// TokenHost is the only host Token is sent to. Token is never sent over
+	// plain HTTP, nor when TokenHost is empty.
+	TokenHost string
+	// NetrcPath is the netrc file searched for HTTPS credentials when Token
+	// is empty. Defaults to $NETRC, or ~/.netrc.
+	NetrcPath string
+	// SSHKeyPath is a private key file for SSH URLs. The ssh-agent is used
+	// when empty.
+	SSHKeyPath string
+	// SSHKeyPassphrase decrypts the key at SSHKeyPath
+	SSHKeyPassphrase string
+}
+
+// AuthOptionsFromEnv returns AuthOptions with the credentials set in the
+// environment, so that tokens do not end up in shell history
+func AuthOptionsFromEnv() AuthOptions {
+	return AuthOptions{
+		Username:         os.Getenv(UsernameEnv),
+		Token:            os.Getenv(TokenEnv),
+		TokenHost:        os.Getenv(TokenHostEnv),
+		SSHKeyPassphrase: os.Getenv(SSHPassphraseEnv),
+	}
+}
+
+// authMethod returns the go-git auth method for [url], or nil to let go-git
+// use its defaults
+func (o AuthOptions) authMethod(url string) (transport.AuthMethod, error) {
+	logger := logs.CreateLogger("git.auth")
+
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.Password != "" {
+		logger.Debug("using credentials from repository URL")
+		return nil, nil
+	}
+
+	switch endpoint.Protocol {
+	case "http", "https":
+		if endpoint.User != "" {
+			logger.Debug("using credentials from repository URL")
+			return nil, nil
+		}
+
+		if o.Token != "" && endpoint.Protocol == "https" && strings.EqualFold(endpoint.Host, o.TokenHost) {
+			logger.Debug("using HTTPS token")
+			return &http.BasicAuth{Username: o.tokenUsername(), Password: o.Token}, nil
+		}
+		if o.Token != "" {
+			logger.Debugf("not sending token to %s://%s", endpoint.Protocol, endpoint.Host)
+		}
+
+		login, password, ok := o.netrcCredentials(endpoint.Host)
+		if ok {
+			logger.Debugf("using netrc credentials for %s", endpoint.Host)
+			return &http.BasicAuth{Username: login, Password: password}, nil
+		}
+		return nil, nil
+
+	case "ssh":
+		user := endpoint.User
+		if user == "" {
+			user = o.Username
+		}
+		if user == "" {
+			user = ssh.DefaultUsername
+		}
+
+		if o.SSHKeyPath != "" {
+			logger.Debugf("using SSH key %s", o.SSHKeyPath)
+			keys, err := ssh.NewPublicKeysFromFile(user, o.SSHKeyPath, o.SSHKeyPassphrase)
+			if err != nil {
+				return nil, fmt.Errorf("%w: failed to read SSH key %s: %v", ErrAuthFailed, o.SSHKeyPath, err)
+			}
+			return keys, nil
+		}
+
+		logger.Debug("using ssh-agent")
+		agent, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, fmt.Errorf("%w: no SSH key configured and ssh-agent is unavailable: %v", ErrAuthFailed, err)
+		}
+		return agent, nil
+	}
+
+	return nil, nil
+}
+
+// URLHost returns the host of the repository at [url], without its port, or
+// an empty string if [url] can not be parsed
+func URLHost(url string) string {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return ""
+	}
+	return endpoint.Host
+}
+
+func (o AuthOptions) tokenUsername() string {
+	if o.Username != "" {
+		return o.Username
+	}
+	return DefaultTokenUsername
+}
+
+func (o AuthOptions) netrcCredentials(host string) (string, string, bool) {
+	path := o.NetrcPath
+	if path == "" {
+		path = os.Getenv("NETRC")
+	}
+	if path == "" {
+		homeDir, err := homedir.Dir()
+		if err != nil {
+			return "", "", false
+		}
+		path = filepath.Join(homeDir, ".netrc")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", false
+	}
+	defer file.Close()
+
+	return parseNetrc(file, host)
+}
+
+// parseNetrc returns the login and password for [host] from a netrc file,
+// falling back to its "default" entry
+func parseNetrc(r io.Reader, host string) (string, string, bool) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	var login, password string
+	var defaultLogin, defaultPassword string
+	inMachine, inDefault, found := false, false, false
+	for scanner.Scan() {
+		switch token := scanner.Text(); token {
+		case "machine":
+			if found {
+				return login, password, true
+			}
+			inDefault = false
+			inMachine = scanner.Scan() && scanner.Text() == host
+			found = inMachine
+		case "default":
+			if found {
+				return login, password, true
+			}
+			inMachine, inDefault = false, true
+		case "login", "password":
+			if !scanner.Scan() {
+				break
+			}
+			value := scanner.Text()
+			switch {
+			case inMachine && token == "login":
+				login = value
+			case inMachine:
+				password = value
+			case inDefault && token == "login":
+				defaultLogin = value
+			case inDefault:
+				defaultPassword = value
+			}
+		case "macdef":
+			// Macro definitions run until an empty line, which the word
+			// scanner can not see, so stop parsing instead
+			if found {
+				return login, password, true
+			}
+			return defaultLogin, defaultPassword, defaultLogin != "" || defaultPassword != ""
+		}
+	}
+
+	if found {
+		return login, password, true
+	}
+	return defaultLogin, defaultPassword, defaultLogin != "" || defaultPassword != ""
+}
+
+// describeAuthError adds a hint on how to authenticate with [url] to
+// authentication errors returned by go-git
+func describeAuthError(url string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed):
+		return fmt.Errorf(
+			"%w: %s rejected the credentials (%v); set %s (and %s for other hosts), add the host to ~/.netrc, or use an SSH URL",
+			ErrAuthFailed,
+			url,
+			err,
+			TokenEnv,
+			TokenHostEnv,
+		)
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		return fmt.Errorf(
+			"%w: %s rejected the SSH key (%v); check --ssh-key or the keys loaded in ssh-agent",
+			ErrAuthFailed,
+			url,
+			err,
+		)
+	}
+
+	return err
+}
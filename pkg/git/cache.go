@@ -0,0 +1,304 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/haroldadmin/getignore/internal/logs"
+)
+
+var (
+	ErrNotCache          = errors.New("not-a-getignore-cache")
+	ErrShallowRepository = errors.New("shallow-repository")
+)
+
+// CacheStatus describes a cached repository
+type CacheStatus struct {
+	Path string
+	// RemoteURL is the URL the repository was cloned from
+	RemoteURL string
+	// Head is the hash of the commit checked out in the repository
+	Head string
+	// LastUpdate is when the repository was last cloned or updated, or the
+	// zero time if it is unknown
+	LastUpdate time.Time
+	Shallow    bool
+	// Managed reports whether the repository was cloned by getignore, which
+	// is the only case where it is ever repaired or deleted
+	Managed bool
+	// DiskSize is the size of the repository directory in bytes
+	DiskSize int64
+}
+
+// Status describes the cached repository in options.RepositoryDir. It only
+// reads the repository: it neither takes its lock nor touches the network,
+// and never repairs, updates or marks it. It fails with ErrNoCache if the
+// repository has not been cloned yet.
+func Status(ctx context.Context, options CreateOptions) (*git.Repository, CacheStatus, error) {
+	logger := logs.CreateLogger("git.status")
+
+	repoPath, err := filepath.Abs(options.RepositoryDir)
+	if err != nil {
+		return nil, CacheStatus{}, ErrInvalidPath
+	}
+
+	repository, err := git.PlainOpen(repoPath)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, CacheStatus{}, fmt.Errorf("%w: %s has not been cloned yet", ErrNoCache, repoPath)
+	}
+	if err != nil {
+		logger.Errorf("failed to open repository: %v", err)
+		return nil, CacheStatus{}, fmt.Errorf("%w: %v", ErrCorruptedCache, err)
+	}
+
+	head, err := HeadCommit(repository)
+	if err != nil {
+		logger.Errorf("failed to read HEAD: %v", err)
+		return nil, CacheStatus{}, fmt.Errorf("%w: invalid HEAD: %v", ErrCorruptedCache, err)
+	}
+
+	size, err := dirSize(repoPath)
+	if err != nil {
+		return nil, CacheStatus{}, err
+	}
+
+	remoteURL := ""
+	if remote, err := repository.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+		remoteURL = remote.Config().URLs[0]
+	}
+
+	repositoryFs := osfs.New(repoPath)
+	return repository, CacheStatus{
+		Path:       repoPath,
+		RemoteURL:  remoteURL,
+		Head:       head,
+		LastUpdate: readLastUpdate(repositoryFs),
+		Shallow:    isShallow(repository),
+		Managed:    isMarked(repositoryFs),
+		DiskSize:   size,
+	}, nil
+}
+
+// Clean deletes the repository in options.RepositoryDir and clones it
+// again. Directories which were not created by getignore are left alone and
+// reported with ErrNotCache.
+func Clean(ctx context.Context, options CreateOptions) (*git.Repository, error) {
+	logger := logs.CreateLogger("git.clean")
+
+	repoPath, err := filepath.Abs(options.RepositoryDir)
+	if err != nil {
+		logger.Errorf("failed to parse absolute path: %v", err)
+		return nil, ErrInvalidPath
+	}
+
+	// Other processes may still be reading the repository deleted here
+	readers, err := acquireReadersLock(ctx, repoPath, true, options.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer readers.release()
+
+	lock, err := acquireLock(ctx, repoPath, options.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	if _, err := os.Stat(repoPath); err == nil {
+		repositoryFs := osfs.New(repoPath)
+		if repository, err := git.PlainOpen(repoPath); err == nil {
+			migrateMarker(repository, repositoryFs, options.remoteURL())
+		}
+		if !isMarked(repositoryFs) {
+			logger.Errorf("%s is not a getignore cache", repoPath)
+			return nil, fmt.Errorf("%w: refusing to delete %s", ErrNotCache, repoPath)
+		}
+
+		logger.Infof("deleting %s", repoPath)
+		if err := os.RemoveAll(repoPath); err != nil {
+			logger.Errorf("failed to delete repository: %v", err)
+			return nil, err
+		}
+	}
+
+	options.Offline = false
+	return create(ctx, repoPath, options, true)
+}
+
+// Verify checks that every object of the commit checked out in the
+// repository in options.RepositoryDir is readable, and that its worktree has
+// no local changes. Repositories created by getignore are repaired by
+// discarding local changes or by cloning them again. Other repositories are
+// left alone and reported with ErrCorruptedCache or ErrLocalChanges.
+func Verify(ctx context.Context, options CreateOptions) (*git.Repository, error) {
+	logger := logs.CreateLogger("git.verify")
+
+	repoPath, err := filepath.Abs(options.RepositoryDir)
+	if err != nil {
+		logger.Errorf("failed to parse absolute path: %v", err)
+		return nil, ErrInvalidPath
+	}
+
+	// Other processes may still be reading the repository if it is cloned again
+	readers, err := acquireReadersLock(ctx, repoPath, true, options.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer readers.release()
+
+	lock, err := acquireLock(ctx, repoPath, options.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	options.UpdateRepository = false
+	options.StaleAfter = 0
+	repository, err := create(ctx, repoPath, options, true)
+	if err != nil {
+		return nil, err
+	}
+
+	repositoryFs := osfs.New(repoPath)
+	err = verifyIntegrity(repository, repositoryFs)
+	if errors.Is(err, ErrCorruptedCache) && !options.Offline && isMarked(repositoryFs) {
+		logger.Warnf("repairing corrupted gitignore repo by cloning it again: %v", err)
+		return reclone(ctx, repoPath, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("verified gitignore repository")
+	return repository, nil
+}
+
+// ForceUpdate updates the repository in options.RepositoryDir right away,
+// cloning it if needed. Unlike Create and Update, it fails if the update
+// fails instead of falling back to the cached repository.
+func ForceUpdate(ctx context.Context, options CreateOptions) (*git.Repository, error) {
+	logger := logs.CreateLogger("git.update")
+
+	repoPath, err := filepath.Abs(options.RepositoryDir)
+	if err != nil {
+		logger.Errorf("failed to parse absolute path: %v", err)
+		return nil, ErrInvalidPath
+	}
+
+	lock, err := acquireLock(ctx, repoPath, options.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	options.UpdateRepository = false
+	options.Offline = false
+	options.StaleAfter = 0
+	repository, err := create(ctx, repoPath, options, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pullOrFetch(ctx, repository, options)
+	if err != nil {
+		return nil, err
+	}
+	writeLastUpdate(osfs.New(repoPath))
+
+	err = checkout(repository, options.Ref)
+	if err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
+
+// GC deletes unreachable objects from the repository in
+// options.RepositoryDir and repacks the remaining ones into a single
+// packfile. It returns the size of the repository before and after. Shallow
+// repositories can not be repacked and fail with ErrShallowRepository.
+func GC(ctx context.Context, options CreateOptions) (int64, int64, error) {
+	logger := logs.CreateLogger("git.gc")
+
+	repoPath, err := filepath.Abs(options.RepositoryDir)
+	if err != nil {
+		logger.Errorf("failed to parse absolute path: %v", err)
+		return 0, 0, ErrInvalidPath
+	}
+
+	// Other processes may still be reading the objects pruned or repacked here
+	readers, err := acquireReadersLock(ctx, repoPath, true, options.LockTimeout)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer readers.release()
+
+	lock, err := acquireLock(ctx, repoPath, options.LockTimeout)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer lock.release()
+
+	// Pruning and repacking need the unwrapped filesystem storage
+	options.Offline = true
+	options.StaleAfter = 0
+	options.Progress = nil
+	repository, err := create(ctx, repoPath, options, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if isShallow(repository) {
+		logger.Errorf("can not repack shallow repository")
+		return 0, 0, fmt.Errorf("%w: objects of shallow clones can not be repacked", ErrShallowRepository)
+	}
+
+	before, err := dirSize(repoPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	logger.Info("pruning unreachable objects")
+	err = repository.Prune(git.PruneOptions{Handler: repository.DeleteObject})
+	if err != nil {
+		message := "failed to prune objects"
+		logger.Errorf("%s: %v", message, err)
+		return 0, 0, fmt.Errorf("%s: %w", message, err)
+	}
+
+	logger.Info("repacking objects")
+	err = repository.RepackObjects(&git.RepackConfig{})
+	if err != nil {
+		message := "failed to repack objects"
+		logger.Errorf("%s: %v", message, err)
+		return 0, 0, fmt.Errorf("%s: %w", message, err)
+	}
+
+	after, err := dirSize(repoPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	logger.Infof("reduced repository from %d to %d bytes", before, after)
+	return before, after, nil
+}
+
+// dirSize returns the total size of the files in [dir]
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
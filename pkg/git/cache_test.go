@@ -0,0 +1,199 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	t.Run("it should describe a cached repository", func(t *testing.T) {
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+		}
+		repo, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+		head, err := HeadCommit(repo)
+		assert.NoError(t, err)
+
+		_, status, err := Status(context.Background(), options)
+		assert.NoError(t, err)
+		assert.Equal(t, options.RemoteURL, status.RemoteURL)
+		assert.Equal(t, head, status.Head)
+		assert.False(t, status.LastUpdate.IsZero())
+		assert.False(t, status.Shallow)
+		assert.True(t, status.Managed)
+		assert.Greater(t, status.DiskSize, int64(0))
+	})
+
+	t.Run("it should not modify the repository", func(t *testing.T) {
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+		}
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		markerPath := filepath.Join(options.RepositoryDir, ".git", cacheMarkerFile)
+		goPath := filepath.Join(options.RepositoryDir, "Go.gitignore")
+		assert.NoError(t, os.Remove(markerPath))
+		assert.NoError(t, ioutil.WriteFile(goPath, []byte("changed\n"), 0644))
+
+		_, status, err := Status(context.Background(), options)
+		assert.NoError(t, err)
+		assert.False(t, status.Managed)
+
+		_, err = os.Stat(markerPath)
+		assert.True(t, os.IsNotExist(err))
+		contents, err := ioutil.ReadFile(goPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "changed\n", string(contents))
+	})
+
+	t.Run("it should return ErrNoCache without cloning", func(t *testing.T) {
+		repoDir := filepath.Join(t.TempDir(), "gitignore")
+		_, _, err := Status(context.Background(), CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     testRemote(t),
+		})
+		assert.True(t, errors.Is(err, ErrNoCache))
+
+		_, err = os.Stat(repoDir)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestClean(t *testing.T) {
+	t.Run("it should delete and clone the repository again", func(t *testing.T) {
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+		}
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		leftover := filepath.Join(options.RepositoryDir, "Leftover.gitignore")
+		assert.NoError(t, ioutil.WriteFile(leftover, []byte("leftover\n"), 0644))
+
+		_, err = Clean(context.Background(), options)
+		assert.NoError(t, err)
+
+		_, err = os.Stat(leftover)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(options.RepositoryDir, "Go.gitignore"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should clean caches cloned by earlier versions", func(t *testing.T) {
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+		}
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+		assert.NoError(t, os.Remove(filepath.Join(options.RepositoryDir, ".git", cacheMarkerFile)))
+
+		_, err = Clean(context.Background(), options)
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should not delete directories which are not caches", func(t *testing.T) {
+		repoDir := t.TempDir()
+		_, err := git.PlainInit(repoDir, false)
+		assert.NoError(t, err)
+
+		_, err = Clean(context.Background(), CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     testRemote(t),
+		})
+		assert.True(t, errors.Is(err, ErrNotCache))
+
+		_, err = os.Stat(filepath.Join(repoDir, ".git"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestForceUpdate(t *testing.T) {
+	t.Run("it should update the repository", func(t *testing.T) {
+		remoteDir := t.TempDir()
+		remote, err := git.PlainInit(remoteDir, false)
+		assert.NoError(t, err)
+		testCommit(t, remote, remoteDir, "Go.gitignore", "v1\n")
+
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     "file://" + filepath.ToSlash(remoteDir),
+		}
+		_, err = Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		testCommit(t, remote, remoteDir, "Go.gitignore", "v2\n")
+		_, err = ForceUpdate(context.Background(), options)
+		assert.NoError(t, err)
+
+		contents, err := ioutil.ReadFile(filepath.Join(options.RepositoryDir, "Go.gitignore"))
+		assert.NoError(t, err)
+		assert.Equal(t, "v2\n", string(contents))
+	})
+
+	t.Run("it should fail when the update fails", func(t *testing.T) {
+		remoteURL := testRemote(t)
+		options := CreateOptions{
+			RepositoryDir:    t.TempDir(),
+			RemoteURL:        remoteURL,
+			UpdateRepository: true,
+		}
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		assert.NoError(t, os.RemoveAll(strings.TrimPrefix(remoteURL, "file://")))
+
+		// Create falls back to the cached repository, ForceUpdate does not
+		_, err = Create(context.Background(), options)
+		assert.NoError(t, err)
+		_, err = ForceUpdate(context.Background(), options)
+		assert.Error(t, err)
+	})
+}
+
+func TestGC(t *testing.T) {
+	t.Run("it should repack the repository", func(t *testing.T) {
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+		}
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		before, after, err := GC(context.Background(), options)
+		assert.NoError(t, err)
+		assert.Greater(t, before, int64(0))
+		assert.Greater(t, after, int64(0))
+
+		repo, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+		_, err = HeadCommit(repo)
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should refuse to repack shallow repositories", func(t *testing.T) {
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+			Depth:         1,
+		}
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		_, _, err = GC(context.Background(), options)
+		assert.True(t, errors.Is(err, ErrShallowRepository))
+	})
+}
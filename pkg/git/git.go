@@ -4,14 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage"
 	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/haroldadmin/getignore/internal/logs"
 	"github.com/mitchellh/go-homedir"
 )
@@ -20,14 +31,95 @@ var (
 	ErrInvalidPath    = errors.New("invalid-path")
 	ErrGitServiceInit = errors.New("git-service-init-failed")
 	ErrChroot         = errors.New("failed-to-chroot")
+	ErrRemoteMismatch = errors.New("remote-mismatch")
+	ErrUnknownRef     = errors.New("unknown-ref")
+	ErrNoCache        = errors.New("no-cached-repository")
+	ErrCorruptedCache = errors.New("corrupted-cache")
+	ErrLocalChanges   = errors.New("local-changes")
 )
 
+// initError reports a failure to initialize the repository. It matches
+// ErrGitServiceInit with errors.Is, and unwraps to the underlying cause.
+type initError struct {
+	err error
+}
+
+func (e *initError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrGitServiceInit, e.err)
+}
+
+func (e *initError) Is(target error) bool {
+	return target == ErrGitServiceInit
+}
+
+func (e *initError) Unwrap() error {
+	return e.err
+}
+
 const GitIgnoreRepository string = "https://github.com/github/gitignore"
 
 // CreateOptions contains config parameters for creating a Git repository
 type CreateOptions struct {
 	RepositoryDir    string
 	UpdateRepository bool
+	// RemoteURL is the URL of the template repository to clone. Any URL
+	// supported by go-git works, including file:// URLs of local mirrors.
+	// Defaults to GitIgnoreRepository when empty.
+	RemoteURL string
+	// Ref pins the repository to a branch, tag or commit. The repository
+	// tracks its default branch when empty.
+	Ref string
+	// Depth limits the number of commits fetched from the tip of each branch.
+	// The full history is cloned when zero. Shallow repositories stay
+	// shallow on update; use Deepen to fetch more history.
+	Depth int
+	// SingleBranch clones only one branch instead of all of them: the
+	// branch named by Ref if it is one, or the default branch otherwise.
+	// Tags pinned with Ref are fetched along with it.
+	SingleBranch bool
+	// Offline uses the cached repository without touching the network. It
+	// fails with ErrNoCache if the repository has not been cloned yet.
+	Offline bool
+	// UpdateInterval is the minimum time between two updates of the
+	// repository. The repository is updated on every call when zero.
+	UpdateInterval time.Duration
+	// DeferUpdate skips a due update so that the cached repository can be
+	// used right away. Callers should run Update afterwards to refresh it.
+	DeferUpdate bool
+	// StaleAfter is the age after which a notice about outdated templates is
+	// logged. No notice is logged when zero.
+	StaleAfter time.Duration
+	// Auth contains credentials for private repositories
+	Auth AuthOptions
+	// Progress receives progress events while cloning or updating
+	Progress ProgressFunc
+	// LockTimeout is how long to wait for other processes cloning or
+	// updating the same repository. Defaults to DefaultLockTimeout when zero.
+	LockTimeout time.Duration
+}
+
+// lastUpdateFile records when the repository was last cloned or updated. It
+// lives inside the .git directory to keep it out of the worktree.
+const lastUpdateFile = "getignore-last-update"
+
+// templateIndexFile stores the templates found in the repository at its
+// current commit, so that they are not listed again on every run. It lives
+// inside the .git directory like lastUpdateFile.
+const templateIndexFile = "getignore-index.json"
+
+// cacheMarkerFile marks a repository directory as created by getignore.
+// Only marked directories are deleted and cloned again when corrupted, so
+// that a --repo-dir pointing at an unrelated repository is never wiped.
+const cacheMarkerFile = "getignore-cache"
+
+// now is replaced in tests
+var now = time.Now
+
+func (o CreateOptions) remoteURL() string {
+	if o.RemoteURL == "" {
+		return GitIgnoreRepository
+	}
+	return o.RemoteURL
 }
 
 func DefaultRepoDir() string {
@@ -38,7 +130,18 @@ func DefaultRepoDir() string {
 	return filepath.Join(homeDir, ".getignore", "gitignore")
 }
 
-// Create creates a Git Repository and returns a reference to it
+// IndexPath returns the file storing the template index of the repository
+// cached in [repoDir]
+func IndexPath(repoDir string) string {
+	return filepath.Join(repoDir, ".git", templateIndexFile)
+}
+
+// Create creates a Git Repository and returns a reference to it.
+// Processes sharing options.RepositoryDir take turns to clone or update it,
+// waiting up to options.LockTimeout for each other. Corrupted repositories
+// are cloned again only while no process holds a ReadLock on them, this one
+// included, and are reported with ErrCorruptedCache otherwise, so that they
+// can be repaired with Verify once released.
 func Create(ctx context.Context, options CreateOptions) (*git.Repository, error) {
 	logger := logs.CreateLogger("git.create")
 	logger.Infof("creating GitService: %s", options.RepositoryDir)
@@ -48,6 +151,27 @@ func Create(ctx context.Context, options CreateOptions) (*git.Repository, error)
 		logger.Errorf("failed to parse absolute path: %v", err)
 		return nil, ErrInvalidPath
 	}
+
+	lock, err := acquireLock(ctx, repoPath, options.LockTimeout)
+	if err != nil {
+		return nil, &initError{err: err}
+	}
+	defer lock.release()
+
+	return create(ctx, repoPath, options, false)
+}
+
+// create opens, clones or updates the repository at [repoPath]. Callers must
+// hold its lock. [readersLocked] reports whether they also hold its readers
+// lock exclusively, so that it can be cloned again if it is corrupted.
+func create(
+	ctx context.Context,
+	repoPath string,
+	options CreateOptions,
+	readersLocked bool,
+) (*git.Repository, error) {
+	logger := logs.CreateLogger("git.create")
+
 	repositoryFs := osfs.New(repoPath)
 
 	dotGitFs, err := repositoryFs.Chroot(".git")
@@ -55,13 +179,79 @@ func Create(ctx context.Context, options CreateOptions) (*git.Repository, error)
 		logger.Errorf("failed to chroot into .git dir: %v", err)
 		return nil, ErrChroot
 	}
-	dotGitStorage := filesystem.NewStorage(dotGitFs, cache.NewObjectLRUDefault())
+	dotGitStorage := withProgress(
+		filesystem.NewStorage(dotGitFs, cache.NewObjectLRUDefault()),
+		options.Progress,
+	)
+
+	repository, err := initialize(ctx, dotGitStorage, repositoryFs, options)
+	if errors.Is(err, ErrCorruptedCache) && !options.Offline && isMarked(repositoryFs) {
+		repository, err = repair(ctx, repoPath, options, readersLocked, err)
+	}
+	if err != nil {
+		return nil, &initError{err: err}
+	}
+
+	return repository, nil
+}
+
+// repair clones the repository at [repoPath] again after it failed to open
+// with [cause]. Deleting it would pull objects from under the processes
+// reading it, so unless [readersLocked], the readers lock is taken without
+// waiting, and the repository is left alone while it is held.
+func repair(
+	ctx context.Context,
+	repoPath string,
+	options CreateOptions,
+	readersLocked bool,
+	cause error,
+) (*git.Repository, error) {
+	logger := logs.CreateLogger("git.repair")
+
+	if !readersLocked {
+		readers, err := tryReadersLock(repoPath)
+		if err != nil {
+			return nil, err
+		}
+		if readers == nil {
+			logger.Warnf("not repairing corrupted gitignore repo while it is being read: %v", cause)
+			return nil, fmt.Errorf("%w (not repaired while it is being read)", cause)
+		}
+		defer readers.release()
+	}
+
+	logger.Warnf("repairing corrupted gitignore repo by cloning it again: %v", cause)
+	return reclone(ctx, repoPath, options)
+}
+
+// reclone deletes the repository at [repoPath] and clones it again. Callers
+// must hold its lock and its readers lock exclusively.
+func reclone(ctx context.Context, repoPath string, options CreateOptions) (*git.Repository, error) {
+	logger := logs.CreateLogger("git.repair")
+
+	err := os.RemoveAll(repoPath)
+	if err != nil {
+		logger.Errorf("failed to delete corrupted repository: %v", err)
+		return nil, err
+	}
+
+	repositoryFs := osfs.New(repoPath)
+	dotGitFs, err := repositoryFs.Chroot(".git")
+	if err != nil {
+		logger.Errorf("failed to chroot into .git dir: %v", err)
+		return nil, ErrChroot
+	}
+	dotGitStorage := withProgress(
+		filesystem.NewStorage(dotGitFs, cache.NewObjectLRUDefault()),
+		options.Progress,
+	)
 
 	repository, err := initialize(ctx, dotGitStorage, repositoryFs, options)
 	if err != nil {
-		return nil, ErrGitServiceInit
+		return nil, err
 	}
 
+	logger.Info("repaired gitignore repository")
 	return repository, nil
 }
 
@@ -82,43 +272,217 @@ func initialize(
 	if err != nil {
 		if !errors.Is(err, git.ErrRepositoryNotExists) {
 			logger.Errorf("failed to open gitignore repo: %v", err)
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", ErrCorruptedCache, err)
 		}
 
 		if errors.Is(err, git.ErrRepositoryNotExists) {
-			repository, err = clone(ctx, storage, filesystem)
+			if options.Offline {
+				logger.Errorf("no cached gitignore repo in offline mode")
+				return nil, fmt.Errorf("%w: run once without --offline to clone it", ErrNoCache)
+			}
+
+			if _, err := filesystem.Stat(filesystem.Join(".git", "config")); err == nil {
+				// A previous clone was interrupted before it wrote HEAD
+				logger.Errorf("found incomplete gitignore repo")
+				return nil, fmt.Errorf("%w: incomplete clone", ErrCorruptedCache)
+			}
+
+			mark(filesystem)
+			repository, err = clone(ctx, storage, filesystem, options)
 			if err != nil {
 				return nil, err
 			}
-			return repository, nil
+			writeLastUpdate(filesystem)
+			return repository, checkout(repository, options.Ref)
 		}
 	}
 
+	err = verifyRemote(repository, options.remoteURL())
+	if err != nil {
+		return nil, err
+	}
+	migrateMarker(repository, filesystem, options.remoteURL())
+
+	_, err = verifyHead(repository)
+	if err != nil {
+		return nil, err
+	}
+
 	logger.Info("GitService initialized")
-	if !options.UpdateRepository {
-		return repository, nil
+	lastUpdate := readLastUpdate(filesystem)
+	updated := false
+	if isUpdateDue(options, lastUpdate) {
+		updated, err = updateOrFetch(ctx, repository, filesystem, options)
+		if err != nil {
+			return repository, err
+		}
+	}
+
+	warnIfStale(options, lastUpdate, updated)
+
+	err = checkout(repository, options.Ref)
+	if errors.Is(err, ErrUnknownRef) && !updated && !options.Offline {
+		// The ref may be newer than the last update skipped by the interval
+		logger.Infof("%q not found in cache, updating repository", options.Ref)
+		updated, err = updateOrFetch(ctx, repository, filesystem, options)
+		if err != nil {
+			return repository, err
+		}
+		err = checkout(repository, options.Ref)
 	}
 
-	err = update(ctx, repository)
+	return repository, err
+}
+
+// Update updates the repository in options.RepositoryDir if an update is
+// due. It is meant to be run after a Create call with DeferUpdate.
+func Update(ctx context.Context, options CreateOptions) error {
+	options.UpdateRepository = true
+	options.DeferUpdate = false
+	options.StaleAfter = 0
+	_, err := Create(ctx, options)
+	return err
+}
+
+// isUpdateDue reports whether the repository last updated at [lastUpdate]
+// should be updated now
+func isUpdateDue(options CreateOptions, lastUpdate time.Time) bool {
+	if !options.UpdateRepository || options.Offline || options.DeferUpdate {
+		return false
+	}
+	return options.UpdateInterval == 0 || now().Sub(lastUpdate) >= options.UpdateInterval
+}
+
+// updateOrFetch updates the repository and records the time of the update.
+// Failed updates are logged and otherwise ignored, unless [ctx] is done or
+// the repository fails verifyIntegrity. It reports whether the update
+// succeeded.
+func updateOrFetch(
+	ctx context.Context,
+	repository *git.Repository,
+	filesystem billy.Filesystem,
+	options CreateOptions,
+) (bool, error) {
+	logger := logs.CreateLogger("git.init")
+
+	err := pullOrFetch(ctx, repository, options)
 	if err != nil {
-		return repository, err
+		if ctx.Err() != nil {
+			return false, err
+		}
+
+		// The update may have failed because of missing objects or local
+		// changes, which are only checked for now as reading every object
+		// is too slow to do on each run
+		if err := verifyIntegrity(repository, filesystem); err != nil {
+			return false, err
+		}
+
+		// A usable clone is already on disk, so a failed update (offline,
+		// outage, firewall) should not prevent using the cached templates
+		logger.Warnf("failed to update gitignore repo, using cached templates: %v", err)
+		return false, nil
 	}
 
-	return repository, nil
+	writeLastUpdate(filesystem)
+	return true, nil
+}
+
+// pullOrFetch updates the repository, or only fetches it if it is pinned to
+// a ref, and returns any error
+func pullOrFetch(ctx context.Context, repository *git.Repository, options CreateOptions) error {
+	if options.Ref != "" {
+		return fetch(ctx, repository, options)
+	}
+	return update(ctx, repository, options)
+}
+
+func warnIfStale(options CreateOptions, lastUpdate time.Time, updated bool) {
+	if updated || options.StaleAfter == 0 || lastUpdate.IsZero() {
+		return
+	}
+
+	age := now().Sub(lastUpdate)
+	if age < options.StaleAfter {
+		return
+	}
+
+	logger := logs.CreateLogger("git.init")
+	logger.Warnf(
+		"templates were last updated %d days ago (%s)",
+		int(age.Hours()/24),
+		lastUpdate.Format("2006-01-02"),
+	)
+}
+
+// readLastUpdate returns the time the repository was last cloned or updated,
+// or the zero time if it is unknown
+func readLastUpdate(filesystem billy.Filesystem) time.Time {
+	file, err := filesystem.Open(filesystem.Join(".git", lastUpdateFile))
+	if err != nil {
+		return time.Time{}
+	}
+	defer file.Close()
+
+	contents, err := ioutil.ReadAll(file)
+	if err != nil {
+		return time.Time{}
+	}
+
+	lastUpdate, err := time.Parse(time.RFC3339, strings.TrimSpace(string(contents)))
+	if err != nil {
+		return time.Time{}
+	}
+	return lastUpdate
+}
+
+func writeLastUpdate(filesystem billy.Filesystem) {
+	logger := logs.CreateLogger("git.init")
+
+	path := filesystem.Join(".git", lastUpdateFile)
+	err := util.WriteFile(filesystem, path, []byte(now().Format(time.RFC3339)), 0644)
+	if err != nil {
+		logger.Warnf("failed to record update time: %v", err)
+	}
 }
 
 func clone(
 	ctx context.Context,
 	storage storage.Storer,
 	filesystem billy.Filesystem,
+	options CreateOptions,
 ) (*git.Repository, error) {
 	logger := logs.CreateLogger("git.clone")
-	logger.Info("cloning gitignore repository")
+	url := options.remoteURL()
+	logger.Infof("cloning gitignore repository from %s", url)
+	auth, err := options.Auth.authMethod(url)
+	if err != nil {
+		logger.Errorf("failed to set up authentication: %v", err)
+		return nil, err
+	}
+
+	referenceName := plumbing.HEAD
+	if options.SingleBranch && options.Ref != "" {
+		referenceName, err = cloneReference(url, auth, options.Ref)
+		if err != nil {
+			err = describeNetworkError(describeAuthError(url, err))
+			message := "failed to list refs of gitignore repo"
+			logger.Errorf("%s: %v", message, err)
+			return nil, fmt.Errorf("%s: %w", message, err)
+		}
+	}
+
 	repository, err := git.CloneContext(ctx, storage, filesystem, &git.CloneOptions{
-		URL: GitIgnoreRepository,
+		URL:           url,
+		Auth:          auth,
+		ReferenceName: referenceName,
+		Depth:         options.Depth,
+		SingleBranch:  options.SingleBranch,
+		Progress:      newProgressWriter(options.Progress),
 	})
 
 	if err != nil {
+		err = describeNetworkError(describeAuthError(url, err))
 		message := "failed to clone gitignore repo"
 		logger.Errorf("%s: %v", message, err)
 		return nil, fmt.Errorf("%s: %w", message, err)
@@ -128,9 +492,36 @@ func clone(
 	return repository, nil
 }
 
+// cloneReference returns the branch to clone so that [ref] is fetched by a
+// single branch clone of [url]. Tags and commits are cloned along with the
+// default branch, so HEAD is returned for them.
+func cloneReference(url string, auth transport.AuthMethod, ref string) (plumbing.ReferenceName, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{url},
+	})
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return "", err
+	}
+
+	branch := plumbing.HEAD
+	for _, remoteRef := range refs {
+		switch remoteRef.Name() {
+		case plumbing.NewTagReferenceName(ref):
+			// Tags take priority over branches, as in ResolveRef
+			return plumbing.HEAD, nil
+		case plumbing.NewBranchReferenceName(ref):
+			branch = remoteRef.Name()
+		}
+	}
+	return branch, nil
+}
+
 func update(
 	ctx context.Context,
 	repository *git.Repository,
+	options CreateOptions,
 ) error {
 	logger := logs.CreateLogger("git.update")
 	logger.Info("pulling latest changes into gitignore repository")
@@ -141,13 +532,33 @@ func update(
 		return fmt.Errorf("%s: %w", message, err)
 	}
 
-	err = worktree.PullContext(ctx, &git.PullOptions{})
+	err = restoreBranch(repository, worktree)
+	if err != nil {
+		return err
+	}
+
+	if isShallow(repository) {
+		return updateShallow(ctx, repository, worktree, options)
+	}
+
+	url := options.remoteURL()
+	auth, err := options.Auth.authMethod(url)
+	if err != nil {
+		logger.Errorf("failed to set up authentication: %v", err)
+		return err
+	}
+
+	err = worktree.PullContext(ctx, &git.PullOptions{
+		Auth:     auth,
+		Progress: newProgressWriter(options.Progress),
+	})
 	if err != nil {
 		if err == git.NoErrAlreadyUpToDate {
 			logger.Info("already up to date")
 			return nil
 		}
 
+		err = describeNetworkError(describeAuthError(url, err))
 		message := "failed to pull latest changes"
 		logger.Errorf("%s: %v", message, err)
 		return fmt.Errorf("%s: %w", message, err)
@@ -156,3 +567,528 @@ func update(
 	logger.Info("pulled latest changes successfully")
 	return nil
 }
+
+// verifyRemote ensures that an existing repository was cloned from [url], so
+// that a cache directory populated from one remote is never reused for another
+func verifyRemote(repository *git.Repository, url string) error {
+	logger := logs.CreateLogger("git.remote")
+
+	remote, err := repository.Remote(git.DefaultRemoteName)
+	if err != nil {
+		message := "failed to read remote of gitignore repository"
+		logger.Errorf("%s: %v", message, err)
+		if errors.Is(err, git.ErrRemoteNotFound) {
+			return fmt.Errorf("%s: %w", message, err)
+		}
+		// Anything else means the config can not be parsed
+		return fmt.Errorf("%w: %s: %v", ErrCorruptedCache, message, err)
+	}
+
+	urls := remote.Config().URLs
+	if len(urls) == 0 || normalizeURL(urls[0]) != normalizeURL(url) {
+		logger.Errorf("repository remote %v does not match %s", urls, url)
+		return fmt.Errorf(
+			"%w: repository was cloned from %v, not %s; use a different --repo-dir",
+			ErrRemoteMismatch,
+			urls,
+			url,
+		)
+	}
+
+	return nil
+}
+
+func normalizeURL(url string) string {
+	url = strings.TrimSuffix(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+	return url
+}
+
+// fetch downloads new objects and refs, including tags, without changing the
+// worktree. It is used instead of update for pinned repositories.
+func fetch(
+	ctx context.Context,
+	repository *git.Repository,
+	options CreateOptions,
+) error {
+	logger := logs.CreateLogger("git.fetch")
+	logger.Info("fetching latest changes into gitignore repository")
+
+	err := fetchRemote(ctx, repository, options, &git.FetchOptions{
+		Tags:  git.AllTags,
+		Depth: shallowDepth(repository, options.Depth),
+	})
+	if err != nil {
+		if err == git.NoErrAlreadyUpToDate {
+			logger.Info("already up to date")
+			return nil
+		}
+
+		message := "failed to fetch latest changes"
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	logger.Info("fetched latest changes successfully")
+	return nil
+}
+
+// ResolveRef resolves a branch, tag or (possibly abbreviated) commit hash to
+// a commit hash. Tags take priority over branches, and branches resolve to
+// their remote-tracking state so that fetched changes are picked up.
+func ResolveRef(repository *git.Repository, ref string) (plumbing.Hash, error) {
+	candidates := []string{
+		plumbing.NewTagReferenceName(ref).String(),
+		plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref).String(),
+		ref,
+	}
+
+	for _, candidate := range candidates {
+		hash, err := repository.ResolveRevision(plumbing.Revision(candidate))
+		if err == nil {
+			return *hash, nil
+		}
+	}
+
+	return plumbing.ZeroHash, fmt.Errorf("%w: %q", ErrUnknownRef, ref)
+}
+
+// checkout pins the worktree of [repository] to [ref] with a detached HEAD.
+// An empty ref restores the default branch instead.
+func checkout(repository *git.Repository, ref string) error {
+	logger := logs.CreateLogger("git.checkout")
+	worktree, err := repository.Worktree()
+	if err != nil {
+		message := "failed to access repository worktree"
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	if ref == "" {
+		return restoreBranch(repository, worktree)
+	}
+
+	hash, err := ResolveRef(repository, ref)
+	if err != nil {
+		logger.Errorf("failed to resolve %q: %v", ref, err)
+		return err
+	}
+
+	head, err := repository.Head()
+	if err == nil && head.Hash() == hash && head.Name() == plumbing.HEAD {
+		logger.Infof("already at %s (%s)", ref, hash)
+		return nil
+	}
+
+	err = worktree.Checkout(&git.CheckoutOptions{
+		Hash:  hash,
+		Force: true,
+	})
+	if err != nil {
+		message := fmt.Sprintf("failed to check out %q", ref)
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	logger.Infof("checked out %s (%s)", ref, hash)
+	return nil
+}
+
+// restoreBranch checks out the local branch of [repository] again if its
+// HEAD was detached by a previously pinned ref
+func restoreBranch(repository *git.Repository, worktree *git.Worktree) error {
+	logger := logs.CreateLogger("git.checkout")
+
+	head, err := repository.Head()
+	if err != nil || head.Name().IsBranch() {
+		return nil
+	}
+
+	branches, err := repository.Branches()
+	if err != nil {
+		message := "failed to list branches"
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	defer branches.Close()
+
+	branch, err := branches.Next()
+	if err != nil {
+		message := "no local branch to restore"
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	err = worktree.Checkout(&git.CheckoutOptions{
+		Branch: branch.Name(),
+		Force:  true,
+	})
+	if err != nil {
+		message := fmt.Sprintf("failed to check out %s", branch.Name().Short())
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	logger.Infof("restored branch %s", branch.Name().Short())
+	return nil
+}
+
+// Deepen replaces the repository in options.RepositoryDir with a clone of
+// [depth] commits, or of the complete history if [depth] is zero. go-git can
+// not deepen shallow repositories in place, so the repository is cloned again
+// next to the existing one and swapped in once the clone succeeds.
+func Deepen(ctx context.Context, options CreateOptions, depth int) (*git.Repository, error) {
+	logger := logs.CreateLogger("git.deepen")
+
+	repoPath, err := filepath.Abs(options.RepositoryDir)
+	if err != nil {
+		logger.Errorf("failed to parse absolute path: %v", err)
+		return nil, ErrInvalidPath
+	}
+
+	// Other processes may still be reading the repository replaced here
+	readers, err := acquireReadersLock(ctx, repoPath, true, options.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer readers.release()
+
+	lock, err := acquireLock(ctx, repoPath, options.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	deepenedPath := repoPath + ".deepen"
+	if err := os.RemoveAll(deepenedPath); err != nil {
+		logger.Errorf("failed to clear %s: %v", deepenedPath, err)
+		return nil, err
+	}
+
+	deepenedOptions := options
+	deepenedOptions.RepositoryDir = deepenedPath
+	deepenedOptions.Depth = depth
+	deepenedOptions.UpdateRepository = false
+	logger.Infof("cloning repository with depth %d", depth)
+	_, err = create(ctx, deepenedPath, deepenedOptions, true)
+	if err != nil {
+		os.RemoveAll(deepenedPath)
+		return nil, err
+	}
+
+	err = swapDir(repoPath, deepenedPath)
+	if err != nil {
+		logger.Errorf("failed to replace repository: %v", err)
+		return nil, err
+	}
+
+	deepenedOptions.RepositoryDir = repoPath
+	logger.Info("deepened repository successfully")
+	return create(ctx, repoPath, deepenedOptions, true)
+}
+
+// swapDir replaces the directory [dest] with [src]
+func swapDir(dest string, src string) error {
+	backup := dest + ".old"
+	if err := os.RemoveAll(backup); err != nil {
+		return err
+	}
+
+	if err := os.Rename(dest, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Rename(src, dest); err != nil {
+		os.Rename(backup, dest)
+		return err
+	}
+
+	return os.RemoveAll(backup)
+}
+
+// HeadCommit returns the hash of the commit checked out in [repository]
+func HeadCommit(repository *git.Repository) (string, error) {
+	head, err := repository.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// isShallow reports whether [repository] was cloned with limited depth
+func isShallow(repository *git.Repository) bool {
+	shallows, err := repository.Storer.Shallow()
+	return err == nil && len(shallows) > 0
+}
+
+// shallowDepth returns the depth to fetch with. Shallow repositories are
+// fetched with at least depth 1 so that they stay shallow.
+func shallowDepth(repository *git.Repository, depth int) int {
+	if depth == 0 && isShallow(repository) {
+		return 1
+	}
+	return depth
+}
+
+// fetchRemote fetches from the default remote of [repository]. Shallow
+// repositories are fetched through a fetchStorer, as go-git cannot negotiate
+// with the missing parents of shallow commits.
+func fetchRemote(
+	ctx context.Context,
+	repository *git.Repository,
+	options CreateOptions,
+	fetchOptions *git.FetchOptions,
+) error {
+	remote, err := repository.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return err
+	}
+
+	if isShallow(repository) {
+		remote = git.NewRemote(newFetchStorer(repository.Storer), remote.Config())
+	}
+
+	url := options.remoteURL()
+	fetchOptions.Auth, err = options.Auth.authMethod(url)
+	if err != nil {
+		return err
+	}
+	fetchOptions.Progress = newProgressWriter(options.Progress)
+
+	err = remote.FetchContext(ctx, fetchOptions)
+	return describeNetworkError(describeAuthError(url, err))
+}
+
+// updateShallow updates the worktree of a shallow repository to the tip of
+// its remote-tracking branch. go-git can not pull into shallow repositories
+// because it walks the history of HEAD to check for fast-forwards, so the
+// branch is fetched and hard reset instead.
+func updateShallow(
+	ctx context.Context,
+	repository *git.Repository,
+	worktree *git.Worktree,
+	options CreateOptions,
+) error {
+	logger := logs.CreateLogger("git.update")
+
+	err := fetchRemote(ctx, repository, options, &git.FetchOptions{
+		Depth: shallowDepth(repository, options.Depth),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		message := "failed to fetch latest changes"
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		message := "failed to read HEAD"
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	remoteBranch := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, head.Name().Short())
+	remoteRef, err := repository.Reference(remoteBranch, true)
+	if err != nil {
+		message := fmt.Sprintf("failed to resolve %s", remoteBranch.Short())
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	if remoteRef.Hash() == head.Hash() {
+		logger.Info("already up to date")
+		return nil
+	}
+
+	err = worktree.Reset(&git.ResetOptions{
+		Commit: remoteRef.Hash(),
+		Mode:   git.HardReset,
+	})
+	if err != nil {
+		message := "failed to reset to latest changes"
+		logger.Errorf("%s: %v", message, err)
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	logger.Info("pulled latest changes successfully")
+	return nil
+}
+
+// fetchStorer wraps the storage of a shallow repository for fetches. It hides
+// local references so that go-git does not walk into the missing parents of
+// shallow commits while computing "haves".
+type fetchStorer struct {
+	storage.Storer
+}
+
+// packfileFetchStorer is a fetchStorer for storages that write packfiles
+// directly, such as the filesystem storage
+type packfileFetchStorer struct {
+	*fetchStorer
+	storer.PackfileWriter
+}
+
+func newFetchStorer(s storage.Storer) storage.Storer {
+	fetchStorer := &fetchStorer{Storer: s}
+	if packfileWriter, ok := s.(storer.PackfileWriter); ok {
+		return &packfileFetchStorer{
+			fetchStorer:    fetchStorer,
+			PackfileWriter: packfileWriter,
+		}
+	}
+	return fetchStorer
+}
+
+func (s *fetchStorer) IterReferences() (storer.ReferenceIter, error) {
+	return storer.NewReferenceSliceIter(nil), nil
+}
+
+// verifyHead checks that HEAD of [repository] resolves to a commit with a
+// readable tree, and returns the tree. It is cheap enough to run on every
+// open, and returns ErrCorruptedCache for problems that can only be fixed by
+// cloning the repository again.
+func verifyHead(repository *git.Repository) (*object.Tree, error) {
+	logger := logs.CreateLogger("git.verify")
+
+	head, err := repository.Head()
+	if err != nil {
+		logger.Errorf("invalid HEAD: %v", err)
+		return nil, fmt.Errorf("%w: invalid HEAD: %v", ErrCorruptedCache, err)
+	}
+
+	commit, err := repository.CommitObject(head.Hash())
+	if err != nil {
+		logger.Errorf("unreadable HEAD commit: %v", err)
+		return nil, fmt.Errorf("%w: unreadable HEAD commit: %v", ErrCorruptedCache, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		logger.Errorf("unreadable HEAD tree: %v", err)
+		return nil, fmt.Errorf("%w: unreadable HEAD tree: %v", ErrCorruptedCache, err)
+	}
+
+	return tree, nil
+}
+
+// verifyIntegrity checks that all objects of the commit checked out in
+// [repository] are readable, and that its worktree has no local changes. It
+// returns ErrCorruptedCache for problems that can only be fixed by cloning
+// the repository again. Local changes are discarded if the repository in
+// [filesystem] was created by getignore, and reported with ErrLocalChanges
+// otherwise.
+func verifyIntegrity(repository *git.Repository, filesystem billy.Filesystem) error {
+	logger := logs.CreateLogger("git.verify")
+
+	tree, err := verifyHead(repository)
+	if err != nil {
+		return err
+	}
+
+	err = tree.Files().ForEach(func(*object.File) error { return nil })
+	if err != nil {
+		logger.Errorf("unreachable objects: %v", err)
+		return fmt.Errorf("%w: unreachable objects: %v", ErrCorruptedCache, err)
+	}
+
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptedCache, err)
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		logger.Errorf("failed to read worktree status: %v", err)
+		return fmt.Errorf("%w: unreadable worktree: %v", ErrCorruptedCache, err)
+	}
+	if status.IsClean() {
+		return nil
+	}
+
+	if !isMarked(filesystem) {
+		logger.Errorf("repository has local changes")
+		return fmt.Errorf(
+			"%w: commit or discard the changes in %s, or use a different --repo-dir",
+			ErrLocalChanges,
+			filesystem.Root(),
+		)
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		return fmt.Errorf("%w: invalid HEAD: %v", ErrCorruptedCache, err)
+	}
+
+	logger.Warnf("gitignore repo has local changes, restoring it to %s", head.Hash())
+	err = worktree.Reset(&git.ResetOptions{
+		Commit: head.Hash(),
+		Mode:   git.HardReset,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to restore worktree: %v", ErrCorruptedCache, err)
+	}
+
+	err = worktree.Clean(&git.CleanOptions{Dir: true})
+	if err != nil {
+		return fmt.Errorf("%w: failed to remove untracked files: %v", ErrCorruptedCache, err)
+	}
+
+	return nil
+}
+
+// mark records that the repository in [filesystem] is managed by getignore.
+// It must only be called for repositories cloned by getignore.
+func mark(filesystem billy.Filesystem) {
+	path := filesystem.Join(".git", cacheMarkerFile)
+	if _, err := filesystem.Stat(path); err == nil {
+		return
+	}
+
+	err := util.WriteFile(filesystem, path, nil, 0644)
+	if err != nil {
+		logger := logs.CreateLogger("git.init")
+		logger.Warnf("failed to mark repository as managed by getignore: %v", err)
+	}
+}
+
+func isMarked(filesystem billy.Filesystem) bool {
+	_, err := filesystem.Stat(filesystem.Join(".git", cacheMarkerFile))
+	return err == nil
+}
+
+// migrateMarker marks [repository] if it was cloned by a version of getignore
+// which did not mark repositories yet, so that it is repaired and cleaned
+// like the repositories cloned since
+func migrateMarker(repository *git.Repository, filesystem billy.Filesystem, url string) {
+	if isMarked(filesystem) || !isLegacyCache(repository, filesystem, url) {
+		return
+	}
+
+	logger := logs.CreateLogger("git.init")
+	logger.Infof("marking %s as managed by getignore", filesystem.Root())
+	mark(filesystem)
+}
+
+// isLegacyCache reports whether the unmarked [repository] was cloned from
+// [url] by getignore: either it records its last update like getignore
+// does, or it lives in the directory of the default cache. Other clones of
+// the same remote, such as a checkout passed with --repo-dir, are left alone.
+func isLegacyCache(repository *git.Repository, filesystem billy.Filesystem, url string) bool {
+	remote, err := repository.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return false
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 || normalizeURL(urls[0]) != normalizeURL(url) {
+		return false
+	}
+
+	if _, err := filesystem.Stat(filesystem.Join(".git", lastUpdateFile)); err == nil {
+		return true
+	}
+	homeDir, err := homedir.Dir()
+	if err != nil {
+		return false
+	}
+	return filepath.Dir(filesystem.Root()) == filepath.Join(homeDir, ".getignore")
+}
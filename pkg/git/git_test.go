@@ -3,10 +3,18 @@ package git
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,9 +26,62 @@ func TestClone(t *testing.T) {
 		fs := memfs.New()
 
 		cancel()
-		_, err := clone(ctx, storage, fs)
+		_, err := clone(ctx, storage, fs, CreateOptions{})
 		assert.Error(t, err, "Expected an error")
 	})
+
+	t.Run("it should clone from a custom remote URL", func(t *testing.T) {
+		remoteURL := testRemote(t)
+		storage := memory.NewStorage()
+		fs := memfs.New()
+
+		repo, err := clone(context.Background(), storage, fs, CreateOptions{
+			RemoteURL: remoteURL,
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, repo)
+
+		_, err = fs.Stat("Go.gitignore")
+		assert.NoError(t, err)
+	})
+}
+
+func TestCreate(t *testing.T) {
+	t.Run("it should refuse to reuse a cache cloned from another remote", func(t *testing.T) {
+		repoDir := t.TempDir()
+		ctx := context.Background()
+
+		_, err := Create(ctx, CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     testRemote(t),
+		})
+		assert.NoError(t, err)
+
+		_, err = Create(ctx, CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     testRemote(t),
+		})
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, ErrRemoteMismatch))
+	})
+
+	t.Run("it should reuse a cache cloned from the same remote", func(t *testing.T) {
+		repoDir := t.TempDir()
+		remoteURL := testRemote(t)
+		ctx := context.Background()
+
+		_, err := Create(ctx, CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     remoteURL,
+		})
+		assert.NoError(t, err)
+
+		_, err = Create(ctx, CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     remoteURL,
+		})
+		assert.NoError(t, err)
+	})
 }
 
 func TestUpdate(t *testing.T) {
@@ -28,7 +89,7 @@ func TestUpdate(t *testing.T) {
 		ctx := context.Background()
 		uninitializedRepo := git.Repository{}
 
-		err := update(ctx, &uninitializedRepo)
+		err := update(ctx, &uninitializedRepo, CreateOptions{})
 		assert.Error(t, err, "Expected an error")
 		assert.True(
 			t,
@@ -46,7 +107,626 @@ func TestUpdate(t *testing.T) {
 		assert.NoError(t, err, "Expected repository to be initialized successfully")
 
 		cancel()
-		err = update(ctx, repo)
+		err = update(ctx, repo, CreateOptions{})
 		assert.Error(t, err, "Expected an error")
 	})
 }
+
+func TestOffline(t *testing.T) {
+	// setup clones a remote into a cache directory, then deletes the remote
+	setup := func(t *testing.T) (string, string) {
+		remoteDir := t.TempDir()
+		repo, err := git.PlainInit(remoteDir, false)
+		assert.NoError(t, err)
+		testCommit(t, repo, remoteDir, "Go.gitignore", "*.exe\n")
+
+		remoteURL := "file://" + filepath.ToSlash(remoteDir)
+		repoDir := t.TempDir()
+		_, err = Create(context.Background(), CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     remoteURL,
+		})
+		assert.NoError(t, err)
+
+		assert.NoError(t, os.RemoveAll(remoteDir))
+		return repoDir, remoteURL
+	}
+
+	t.Run("it should fail in offline mode without a cached repository", func(t *testing.T) {
+		_, err := Create(context.Background(), CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+			Offline:       true,
+		})
+		assert.True(t, errors.Is(err, ErrNoCache))
+	})
+
+	t.Run("it should use the cached repository in offline mode", func(t *testing.T) {
+		repoDir, remoteURL := setup(t)
+		repo, err := Create(context.Background(), CreateOptions{
+			RepositoryDir:    repoDir,
+			RemoteURL:        remoteURL,
+			UpdateRepository: true,
+			Offline:          true,
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, repo)
+	})
+
+	t.Run("it should fall back to the cached repository if the update fails", func(t *testing.T) {
+		repoDir, remoteURL := setup(t)
+		repo, err := Create(context.Background(), CreateOptions{
+			RepositoryDir:    repoDir,
+			RemoteURL:        remoteURL,
+			UpdateRepository: true,
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, repo)
+
+		_, err = os.Stat(filepath.Join(repoDir, "Go.gitignore"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should not fall back if the context is cancelled", func(t *testing.T) {
+		repoDir, remoteURL := setup(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := Create(ctx, CreateOptions{
+			RepositoryDir:    repoDir,
+			RemoteURL:        remoteURL,
+			UpdateRepository: true,
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestUpdateInterval(t *testing.T) {
+	// setup clones a remote, commits new contents to it and returns the
+	// options to open the clone with
+	setup := func(t *testing.T) CreateOptions {
+		remoteDir := t.TempDir()
+		remote, err := git.PlainInit(remoteDir, false)
+		assert.NoError(t, err)
+		testCommit(t, remote, remoteDir, "Go.gitignore", "old\n")
+
+		options := CreateOptions{
+			RepositoryDir:    t.TempDir(),
+			RemoteURL:        "file://" + filepath.ToSlash(remoteDir),
+			UpdateRepository: true,
+			UpdateInterval:   time.Hour,
+		}
+		_, err = Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		testCommit(t, remote, remoteDir, "Go.gitignore", "new\n")
+		return options
+	}
+
+	contents := func(t *testing.T, options CreateOptions) string {
+		contents, err := ioutil.ReadFile(filepath.Join(options.RepositoryDir, "Go.gitignore"))
+		assert.NoError(t, err)
+		return string(contents)
+	}
+
+	withClock := func(t *testing.T, offset time.Duration) {
+		now = func() time.Time { return time.Now().Add(offset) }
+		t.Cleanup(func() { now = time.Now })
+	}
+
+	t.Run("it should not update before the interval has passed", func(t *testing.T) {
+		options := setup(t)
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+		assert.Equal(t, "old\n", contents(t, options))
+	})
+
+	t.Run("it should update once the interval has passed", func(t *testing.T) {
+		options := setup(t)
+		withClock(t, 2*time.Hour)
+
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+		assert.Equal(t, "new\n", contents(t, options))
+	})
+
+	t.Run("it should defer due updates until Update is called", func(t *testing.T) {
+		options := setup(t)
+		options.DeferUpdate = true
+		withClock(t, 2*time.Hour)
+
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+		assert.Equal(t, "old\n", contents(t, options))
+
+		err = Update(context.Background(), options)
+		assert.NoError(t, err)
+		assert.Equal(t, "new\n", contents(t, options))
+	})
+
+	t.Run("it should record the time of the last update", func(t *testing.T) {
+		fs := memfs.New()
+		assert.True(t, readLastUpdate(fs).IsZero())
+
+		writeLastUpdate(fs)
+		assert.WithinDuration(t, time.Now(), readLastUpdate(fs), time.Minute)
+	})
+}
+
+func TestRepair(t *testing.T) {
+	// setup clones a test remote and returns the options used for it
+	setup := func(t *testing.T) CreateOptions {
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+		}
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+		return options
+	}
+
+	// unmark makes the repository look like a clone getignore did not create
+	unmark := func(t *testing.T, options CreateOptions) {
+		assert.NoError(t, os.Remove(filepath.Join(options.RepositoryDir, ".git", cacheMarkerFile)))
+		assert.NoError(t, os.Remove(filepath.Join(options.RepositoryDir, ".git", lastUpdateFile)))
+	}
+
+	assertRepaired := func(t *testing.T, options CreateOptions) {
+		repo, err := Verify(context.Background(), options)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, verifyIntegrity(repo, osfs.New(options.RepositoryDir)))
+
+		contents, err := ioutil.ReadFile(filepath.Join(options.RepositoryDir, "Go.gitignore"))
+		assert.NoError(t, err)
+		assert.Equal(t, "*.exe\n", string(contents))
+	}
+
+	t.Run("it should clone again if HEAD is missing", func(t *testing.T) {
+		options := setup(t)
+		assert.NoError(t, os.Remove(filepath.Join(options.RepositoryDir, ".git", "HEAD")))
+		assertRepaired(t, options)
+	})
+
+	t.Run("it should clone again if objects are missing", func(t *testing.T) {
+		options := setup(t)
+		assert.NoError(t, os.RemoveAll(filepath.Join(options.RepositoryDir, ".git", "objects")))
+		assertRepaired(t, options)
+	})
+
+	t.Run("it should clone again if the config is corrupted", func(t *testing.T) {
+		options := setup(t)
+		configPath := filepath.Join(options.RepositoryDir, ".git", "config")
+		assert.NoError(t, ioutil.WriteFile(configPath, []byte("[remote \"orig"), 0644))
+		assertRepaired(t, options)
+	})
+
+	t.Run("it should restore local changes and remove untracked files", func(t *testing.T) {
+		options := setup(t)
+		goPath := filepath.Join(options.RepositoryDir, "Go.gitignore")
+		untrackedPath := filepath.Join(options.RepositoryDir, "Untracked.gitignore")
+		assert.NoError(t, ioutil.WriteFile(goPath, []byte("changed\n"), 0644))
+		assert.NoError(t, ioutil.WriteFile(untrackedPath, []byte("untracked\n"), 0644))
+
+		assertRepaired(t, options)
+		_, err := os.Stat(untrackedPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("it should report local changes in repositories it did not create", func(t *testing.T) {
+		options := setup(t)
+		unmark(t, options)
+		goPath := filepath.Join(options.RepositoryDir, "Go.gitignore")
+		assert.NoError(t, ioutil.WriteFile(goPath, []byte("changed\n"), 0644))
+
+		_, err := Verify(context.Background(), options)
+		assert.True(t, errors.Is(err, ErrLocalChanges))
+
+		contents, err := ioutil.ReadFile(goPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "changed\n", string(contents))
+	})
+
+	t.Run("it should not mark repositories it did not create", func(t *testing.T) {
+		options := setup(t)
+		unmark(t, options)
+		markerPath := filepath.Join(options.RepositoryDir, ".git", cacheMarkerFile)
+
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		_, err = os.Stat(markerPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("it should not delete directories it did not create", func(t *testing.T) {
+		options := setup(t)
+		unmark(t, options)
+		assert.NoError(t, os.RemoveAll(filepath.Join(options.RepositoryDir, ".git", "objects")))
+
+		_, err := Create(context.Background(), options)
+		assert.True(t, errors.Is(err, ErrCorruptedCache))
+	})
+
+	t.Run("it should not clone caches again while they are read", func(t *testing.T) {
+		options := setup(t)
+		goPath := filepath.Join(options.RepositoryDir, "Go.gitignore")
+		assert.NoError(t, os.Remove(filepath.Join(options.RepositoryDir, ".git", "HEAD")))
+
+		readLock, err := AcquireReadLock(context.Background(), options)
+		assert.NoError(t, err)
+		_, err = Create(context.Background(), options)
+		assert.True(t, errors.Is(err, ErrCorruptedCache))
+		_, err = os.Stat(goPath)
+		assert.NoError(t, err)
+		readLock.Release()
+
+		_, err = Create(context.Background(), options)
+		assert.NoError(t, err)
+		assertRepaired(t, options)
+	})
+
+	t.Run("it should mark and repair caches cloned by earlier versions", func(t *testing.T) {
+		options := setup(t)
+		markerPath := filepath.Join(options.RepositoryDir, ".git", cacheMarkerFile)
+		assert.NoError(t, os.Remove(markerPath))
+		assert.NoError(t, os.RemoveAll(filepath.Join(options.RepositoryDir, ".git", "objects")))
+
+		_, err := Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		_, err = os.Stat(markerPath)
+		assert.NoError(t, err)
+		contents, err := ioutil.ReadFile(filepath.Join(options.RepositoryDir, "Go.gitignore"))
+		assert.NoError(t, err)
+		assert.Equal(t, "*.exe\n", string(contents))
+	})
+
+	t.Run("it should not mark other clones of the remote", func(t *testing.T) {
+		remoteURL := testRemote(t)
+		repoDir := t.TempDir()
+		_, err := git.PlainClone(repoDir, false, &git.CloneOptions{URL: remoteURL})
+		assert.NoError(t, err)
+
+		_, err = Create(context.Background(), CreateOptions{RepositoryDir: repoDir, RemoteURL: remoteURL})
+		assert.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(repoDir, ".git", cacheMarkerFile))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("it should not delete the cache in offline mode", func(t *testing.T) {
+		options := setup(t)
+		options.Offline = true
+		assert.NoError(t, os.RemoveAll(filepath.Join(options.RepositoryDir, ".git", "objects")))
+
+		_, err := Create(context.Background(), options)
+		assert.True(t, errors.Is(err, ErrCorruptedCache))
+
+		_, err = os.Stat(filepath.Join(options.RepositoryDir, "Go.gitignore"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestCheckout(t *testing.T) {
+	// setup creates a remote with a tagged first commit and a second commit,
+	// and returns the remote URL and both commit hashes
+	setup := func(t *testing.T) (string, plumbing.Hash, plumbing.Hash) {
+		dir := t.TempDir()
+		repo, err := git.PlainInit(dir, false)
+		assert.NoError(t, err)
+
+		testCommit(t, repo, dir, "Go.gitignore", "v1\n")
+		first, err := repo.Head()
+		assert.NoError(t, err)
+		_, err = repo.CreateTag("v1", first.Hash(), nil)
+		assert.NoError(t, err)
+
+		testCommit(t, repo, dir, "Go.gitignore", "v2\n")
+		second, err := repo.Head()
+		assert.NoError(t, err)
+
+		return "file://" + filepath.ToSlash(dir), first.Hash(), second.Hash()
+	}
+
+	create := func(t *testing.T, repoDir, remoteURL, ref string) *git.Repository {
+		repo, err := Create(context.Background(), CreateOptions{
+			RepositoryDir:    repoDir,
+			RemoteURL:        remoteURL,
+			UpdateRepository: true,
+			Ref:              ref,
+		})
+		assert.NoError(t, err)
+		return repo
+	}
+
+	t.Run("it should check out a tag", func(t *testing.T) {
+		remoteURL, first, _ := setup(t)
+		repo := create(t, t.TempDir(), remoteURL, "v1")
+
+		commit, err := HeadCommit(repo)
+		assert.NoError(t, err)
+		assert.Equal(t, first.String(), commit)
+	})
+
+	t.Run("it should check out an abbreviated commit hash", func(t *testing.T) {
+		remoteURL, first, _ := setup(t)
+		repo := create(t, t.TempDir(), remoteURL, first.String()[:8])
+
+		commit, err := HeadCommit(repo)
+		assert.NoError(t, err)
+		assert.Equal(t, first.String(), commit)
+	})
+
+	t.Run("it should switch between pinned and unpinned states", func(t *testing.T) {
+		remoteURL, first, second := setup(t)
+		repoDir := t.TempDir()
+
+		repo := create(t, repoDir, remoteURL, "v1")
+		commit, _ := HeadCommit(repo)
+		assert.Equal(t, first.String(), commit)
+
+		repo = create(t, repoDir, remoteURL, "")
+		head, err := repo.Head()
+		assert.NoError(t, err)
+		assert.True(t, head.Name().IsBranch())
+		assert.Equal(t, second, head.Hash())
+
+		contents, err := ioutil.ReadFile(filepath.Join(repoDir, "Go.gitignore"))
+		assert.NoError(t, err)
+		assert.Equal(t, "v2\n", string(contents))
+	})
+
+	t.Run("it should return an error for unknown refs", func(t *testing.T) {
+		remoteURL, _, _ := setup(t)
+		_, err := Create(context.Background(), CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     remoteURL,
+			Ref:           "does-not-exist",
+		})
+		assert.True(t, errors.Is(err, ErrUnknownRef))
+	})
+}
+
+func TestShallow(t *testing.T) {
+	// setup creates a remote with [commits] commits and returns its URL
+	setup := func(t *testing.T, commits int) (string, *git.Repository, string) {
+		dir := t.TempDir()
+		repo, err := git.PlainInit(dir, false)
+		assert.NoError(t, err)
+
+		for i := 0; i < commits; i++ {
+			testCommit(t, repo, dir, "Go.gitignore", fmt.Sprintf("v%d\n", i))
+		}
+
+		return "file://" + filepath.ToSlash(dir), repo, dir
+	}
+
+	options := func(repoDir, remoteURL string) CreateOptions {
+		return CreateOptions{
+			RepositoryDir:    repoDir,
+			RemoteURL:        remoteURL,
+			UpdateRepository: true,
+			Depth:            1,
+			SingleBranch:     true,
+		}
+	}
+
+	t.Run("it should clone only the requested depth", func(t *testing.T) {
+		remoteURL, _, _ := setup(t, 3)
+		repo, err := Create(context.Background(), options(t.TempDir(), remoteURL))
+		assert.NoError(t, err)
+
+		assert.True(t, isShallow(repo))
+		assert.Equal(t, 1, commitCount(t, repo))
+	})
+
+	t.Run("it should update shallow clones", func(t *testing.T) {
+		remoteURL, remote, remoteDir := setup(t, 3)
+		repoDir := t.TempDir()
+		_, err := Create(context.Background(), options(repoDir, remoteURL))
+		assert.NoError(t, err)
+
+		testCommit(t, remote, remoteDir, "Go.gitignore", "latest\n")
+		repo, err := Create(context.Background(), options(repoDir, remoteURL))
+		assert.NoError(t, err)
+
+		contents, err := ioutil.ReadFile(filepath.Join(repoDir, "Go.gitignore"))
+		assert.NoError(t, err)
+		assert.Equal(t, "latest\n", string(contents))
+		assert.True(t, isShallow(repo))
+	})
+
+	t.Run("it should deepen shallow clones", func(t *testing.T) {
+		remoteURL, _, _ := setup(t, 5)
+		createOptions := options(t.TempDir(), remoteURL)
+		_, err := Create(context.Background(), createOptions)
+		assert.NoError(t, err)
+
+		repo, err := Deepen(context.Background(), createOptions, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, commitCount(t, repo))
+		assert.True(t, isShallow(repo))
+
+		repo, err = Deepen(context.Background(), createOptions, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, commitCount(t, repo))
+		assert.False(t, isShallow(repo))
+	})
+
+	t.Run("it should clone a branch other than the default one", func(t *testing.T) {
+		remoteURL, remote, remoteDir := setup(t, 2)
+		worktree, err := remote.Worktree()
+		assert.NoError(t, err)
+		assert.NoError(t, worktree.Checkout(&git.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName("dev"),
+			Create: true,
+		}))
+		testCommit(t, remote, remoteDir, "Go.gitignore", "dev\n")
+		dev, err := remote.Head()
+		assert.NoError(t, err)
+		assert.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.Master}))
+
+		repoDir := t.TempDir()
+		createOptions := options(repoDir, remoteURL)
+		createOptions.Ref = "dev"
+		repo, err := Create(context.Background(), createOptions)
+		assert.NoError(t, err)
+
+		commit, err := HeadCommit(repo)
+		assert.NoError(t, err)
+		assert.Equal(t, dev.Hash().String(), commit)
+		assert.True(t, isShallow(repo))
+
+		// Later runs fetch the branch too
+		assert.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("dev")}))
+		testCommit(t, remote, remoteDir, "Go.gitignore", "dev 2\n")
+		repo, err = Create(context.Background(), createOptions)
+		assert.NoError(t, err)
+
+		contents, err := ioutil.ReadFile(filepath.Join(repoDir, "Go.gitignore"))
+		assert.NoError(t, err)
+		assert.Equal(t, "dev 2\n", string(contents))
+	})
+}
+
+func TestLock(t *testing.T) {
+	t.Run("it should time out while another process holds the lock", func(t *testing.T) {
+		repoDir := t.TempDir()
+		lock, err := acquireLock(context.Background(), repoDir, 0)
+		assert.NoError(t, err)
+		defer lock.release()
+
+		_, err = Create(context.Background(), CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     testRemote(t),
+			LockTimeout:   200 * time.Millisecond,
+		})
+		assert.True(t, errors.Is(err, ErrLockTimeout))
+		assert.True(t, errors.Is(err, ErrGitServiceInit))
+	})
+
+	t.Run("it should wait until the lock is released", func(t *testing.T) {
+		repoDir := t.TempDir()
+		lock, err := acquireLock(context.Background(), repoDir, 0)
+		assert.NoError(t, err)
+
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			lock.release()
+		}()
+
+		_, err = Create(context.Background(), CreateOptions{
+			RepositoryDir: repoDir,
+			RemoteURL:     testRemote(t),
+			LockTimeout:   10 * time.Second,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should stop waiting when the context is cancelled", func(t *testing.T) {
+		repoDir := t.TempDir()
+		lock, err := acquireLock(context.Background(), repoDir, 0)
+		assert.NoError(t, err)
+		defer lock.release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, err = acquireLock(ctx, repoDir, 10*time.Second)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("it should let readers share the repository", func(t *testing.T) {
+		options := CreateOptions{RepositoryDir: t.TempDir(), LockTimeout: 200 * time.Millisecond}
+		first, err := AcquireReadLock(context.Background(), options)
+		assert.NoError(t, err)
+		defer first.Release()
+
+		second, err := AcquireReadLock(context.Background(), options)
+		assert.NoError(t, err)
+		second.Release()
+		second.Release()
+	})
+
+	t.Run("it should not garbage collect the repository while it is read", func(t *testing.T) {
+		options := CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+			LockTimeout:   200 * time.Millisecond,
+		}
+		readLock, err := AcquireReadLock(context.Background(), options)
+		assert.NoError(t, err)
+		_, err = Create(context.Background(), options)
+		assert.NoError(t, err)
+
+		_, _, err = GC(context.Background(), options)
+		assert.True(t, errors.Is(err, ErrLockTimeout))
+
+		readLock.Release()
+		_, _, err = GC(context.Background(), options)
+		assert.NoError(t, err)
+	})
+}
+
+func commitCount(t *testing.T, repo *git.Repository) int {
+	t.Helper()
+
+	commits, err := repo.Log(&git.LogOptions{})
+	assert.NoError(t, err)
+
+	count := 0
+	_ = commits.ForEach(func(*object.Commit) error {
+		count++
+		return nil
+	})
+	return count
+}
+
+// testRemote creates a local repository with a single commit containing a
+// Go.gitignore file, and returns its file:// URL
+func testRemote(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init test remote: %v", err)
+	}
+
+	testCommit(t, repo, dir, "Go.gitignore", "*.exe\n")
+
+	return "file://" + filepath.ToSlash(dir)
+}
+
+// testCommit writes [contents] to [name] in the worktree of [repo] rooted at
+// [dir], and commits it
+func testCommit(t *testing.T, repo *git.Repository, dir, name, contents string) {
+	t.Helper()
+
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)
+	if err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to open worktree: %v", err)
+	}
+
+	_, err = worktree.Add(name)
+	if err != nil {
+		t.Fatalf("failed to add %s: %v", name, err)
+	}
+
+	_, err = worktree.Commit("Update "+name, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "getignore",
+			Email: "getignore@example.com",
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit %s: %v", name, err)
+	}
+}
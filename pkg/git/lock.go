@@ -0,0 +1,203 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/haroldadmin/getignore/internal/logs"
+)
+
+var ErrLockTimeout = errors.New("lock-timeout")
+
+// DefaultLockTimeout is how long Create waits for other getignore processes
+// to finish cloning or updating the same repository
+const DefaultLockTimeout = 2 * time.Minute
+
+// lockPollInterval is how often a held lock is retried
+const lockPollInterval = 100 * time.Millisecond
+
+// repoLock is an advisory lock on a repository directory, shared by all
+// processes using it. The lock file lives next to the repository rather than
+// inside it, so that it survives the directory being deleted and cloned again.
+type repoLock struct {
+	file *os.File
+}
+
+func lockPath(repoPath string) string {
+	return repoPath + ".lock"
+}
+
+// readersLockPath is the lock held shared by processes reading templates
+// from the repository, and exclusively by operations which delete objects
+// that readers may still need, such as GC. It is separate from the lock at
+// lockPath so that updates do not wait for readers.
+func readersLockPath(repoPath string) string {
+	return repoPath + ".readers.lock"
+}
+
+// acquireLock waits until the repository at [repoPath] is not locked by any
+// other process and locks it. It gives up with ErrLockTimeout after
+// [timeout], or DefaultLockTimeout when zero.
+func acquireLock(ctx context.Context, repoPath string, timeout time.Duration) (*repoLock, error) {
+	return acquireFileLock(ctx, lockPath(repoPath), repoPath, true, timeout)
+}
+
+// acquireReadersLock locks the readers lock of the repository at
+// [repoPath], shared or exclusively. Processes which take both locks must
+// take this one first.
+func acquireReadersLock(
+	ctx context.Context,
+	repoPath string,
+	exclusive bool,
+	timeout time.Duration,
+) (*repoLock, error) {
+	return acquireFileLock(ctx, readersLockPath(repoPath), repoPath, exclusive, timeout)
+}
+
+// acquireFileLock waits until the lock file at [path] of the repository at
+// [repoPath] can be locked, and locks it
+func acquireFileLock(
+	ctx context.Context,
+	path string,
+	repoPath string,
+	exclusive bool,
+	timeout time.Duration,
+) (*repoLock, error) {
+	logger := logs.CreateLogger("git.lock")
+
+	if timeout == 0 {
+		timeout = DefaultLockTimeout
+	}
+
+	file, err := openLockFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	waiting := false
+	for {
+		locked, err := tryLockFile(file, exclusive)
+		if err != nil {
+			file.Close()
+			logger.Errorf("failed to lock %s: %v", path, err)
+			return nil, err
+		}
+		if locked {
+			logger.Debugf("locked %s", path)
+			return &repoLock{file: file}, nil
+		}
+
+		if !waiting {
+			waiting = true
+			logger.Infof("waiting for another getignore process to release %s", path)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			file.Close()
+			logger.Errorf("timed out waiting for %s", path)
+			return nil, fmt.Errorf(
+				"%w: %s is still in use by another getignore process after %s",
+				ErrLockTimeout,
+				repoPath,
+				timeout,
+			)
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// tryReadersLock locks the readers lock of the repository at [repoPath]
+// exclusively if no process holds it, this one included. It returns nil
+// without waiting otherwise.
+func tryReadersLock(repoPath string) (*repoLock, error) {
+	logger := logs.CreateLogger("git.lock")
+
+	path := readersLockPath(repoPath)
+	file, err := openLockFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := tryLockFile(file, true)
+	if err != nil {
+		file.Close()
+		logger.Errorf("failed to lock %s: %v", path, err)
+		return nil, err
+	}
+	if !locked {
+		file.Close()
+		return nil, nil
+	}
+	logger.Debugf("locked %s", path)
+	return &repoLock{file: file}, nil
+}
+
+// openLockFile opens the lock file at [path], creating it if needed
+func openLockFile(path string) (*os.File, error) {
+	logger := logs.CreateLogger("git.lock")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logger.Errorf("failed to create directory for lock file: %v", err)
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		logger.Errorf("failed to open lock file: %v", err)
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadLock keeps other processes from deleting objects of a repository, with
+// GC, Clean, Deepen or by cloning it again to repair it, while templates are
+// read from it. Any number of processes can hold it at once.
+type ReadLock struct {
+	lock *repoLock
+	once sync.Once
+}
+
+// AcquireReadLock takes a ReadLock on the repository in
+// options.RepositoryDir. It must be taken before Create, and released once
+// the templates have been read.
+func AcquireReadLock(ctx context.Context, options CreateOptions) (*ReadLock, error) {
+	repoPath, err := filepath.Abs(options.RepositoryDir)
+	if err != nil {
+		return nil, ErrInvalidPath
+	}
+
+	lock, err := acquireReadersLock(ctx, repoPath, false, options.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &ReadLock{lock: lock}, nil
+}
+
+// Release releases the lock. It is safe to call more than once.
+func (l *ReadLock) Release() {
+	l.once.Do(l.lock.release)
+}
+
+// release unlocks the repository. Closing the file releases the lock, which
+// the operating system also does if the process dies while holding it.
+func (l *repoLock) release() {
+	logger := logs.CreateLogger("git.lock")
+	if err := unlockFile(l.file); err != nil {
+		logger.Warnf("failed to unlock %s: %v", l.file.Name(), err)
+	}
+	l.file.Close()
+}
@@ -0,0 +1,37 @@
+//go:build windows
+// +build windows
+
+package git
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// tryLockFile takes an exclusive or shared lock on [file] without blocking.
+// It reports false if another process holds a conflicting lock.
+func tryLockFile(file *os.File, exclusive bool) (bool, error) {
+	flags := uint32(windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if exclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	overlapped := new(windows.Overlapped)
+	err := windows.LockFileEx(
+		windows.Handle(file.Fd()),
+		flags,
+		0,
+		1,
+		0,
+		overlapped,
+	)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlockFile(file *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, new(windows.Overlapped))
+}
@@ -0,0 +1,268 @@
+package git
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage"
+)
+
+// ProgressPhase is a stage of a clone or update
+type ProgressPhase string
+
+const (
+	// PhaseCounting is the remote counting the objects to send
+	PhaseCounting ProgressPhase = "counting"
+	// PhaseCompressing is the remote compressing the objects to send
+	PhaseCompressing ProgressPhase = "compressing"
+	// PhaseReceiving is the packfile being downloaded and indexed
+	PhaseReceiving ProgressPhase = "receiving"
+)
+
+// ProgressEvent describes how far a clone or update has come
+type ProgressEvent struct {
+	Phase ProgressPhase
+	// Current is the number of objects processed in Phase
+	Current int
+	// Total is the number of objects to process in Phase, or zero if unknown
+	Total int
+	// Bytes is the size of the packfile received so far
+	Bytes int64
+	// Done is set on the last event of a phase
+	Done bool
+}
+
+// ProgressFunc receives progress events. It is called from the goroutines
+// doing the transfer, so it should return quickly.
+type ProgressFunc func(event ProgressEvent)
+
+// remoteProgressPattern matches progress messages sent by git servers, such
+// as "Counting objects: 45% (9/20)" or "Compressing objects: 100% (3/3), done."
+var remoteProgressPattern = regexp.MustCompile(`^([A-Za-z ]+): +\d+% \((\d+)/(\d+)\)(, done)?`)
+
+// remoteCountPattern matches progress messages without a total, such as
+// "Enumerating objects: 20, done."
+var remoteCountPattern = regexp.MustCompile(`^([A-Za-z ]+): +(\d+)(, done)?`)
+
+// progressWriter parses the progress messages of a git server into events.
+// Messages may be split across writes and are terminated by \r or \n.
+type progressWriter struct {
+	report  ProgressFunc
+	pending string
+}
+
+func newProgressWriter(report ProgressFunc) io.Writer {
+	if report == nil {
+		return nil
+	}
+	return &progressWriter{report: report}
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.pending += string(p)
+	for {
+		index := strings.IndexAny(w.pending, "\r\n")
+		if index < 0 {
+			return len(p), nil
+		}
+
+		line := w.pending[:index]
+		w.pending = w.pending[index+1:]
+		if event, ok := parseRemoteProgress(line); ok {
+			w.report(event)
+		}
+	}
+}
+
+func parseRemoteProgress(line string) (ProgressEvent, bool) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "remote:"))
+
+	if match := remoteProgressPattern.FindStringSubmatch(line); match != nil {
+		current, _ := strconv.Atoi(match[2])
+		total, _ := strconv.Atoi(match[3])
+		return ProgressEvent{
+			Phase:   remotePhase(match[1]),
+			Current: current,
+			Total:   total,
+			Done:    match[4] != "",
+		}, true
+	}
+
+	if match := remoteCountPattern.FindStringSubmatch(line); match != nil {
+		current, _ := strconv.Atoi(match[2])
+		return ProgressEvent{
+			Phase:   remotePhase(match[1]),
+			Current: current,
+			Done:    match[3] != "",
+		}, true
+	}
+
+	return ProgressEvent{}, false
+}
+
+// remotePhase maps the name of a server side phase, such as
+// "Counting objects", to a ProgressPhase
+func remotePhase(name string) ProgressPhase {
+	switch name {
+	case "Enumerating objects", "Counting objects":
+		return PhaseCounting
+	case "Compressing objects":
+		return PhaseCompressing
+	}
+	return ProgressPhase(strings.ToLower(strings.TrimSuffix(name, " objects")))
+}
+
+// progressStorer wraps a storage to report the bytes of received packfiles.
+// It keeps the optional interfaces of the filesystem storage that go-git
+// relies on.
+type progressStorer struct {
+	storage.Storer
+	report ProgressFunc
+}
+
+func withProgress(s storage.Storer, report ProgressFunc) storage.Storer {
+	if report == nil {
+		return s
+	}
+	if _, ok := s.(storer.PackfileWriter); !ok {
+		return s
+	}
+	return &progressStorer{Storer: s, report: report}
+}
+
+func (s *progressStorer) Init() error {
+	if initializer, ok := s.Storer.(storer.Initializer); ok {
+		return initializer.Init()
+	}
+	return nil
+}
+
+func (s *progressStorer) PackfileWriter() (io.WriteCloser, error) {
+	writer, err := s.Storer.(storer.PackfileWriter).PackfileWriter()
+	if err != nil {
+		return nil, err
+	}
+	return newCountingWriter(writer, s.report), nil
+}
+
+// packHeaderSize is the size of the packfile signature, version and number
+// of objects
+const packHeaderSize = 12
+
+// countingWriter reports the bytes written to a packfile, and the number of
+// objects received so far once its header has been written. The packfile is
+// fed to a scanner as it is written to count the objects it contains.
+type countingWriter struct {
+	io.WriteCloser
+	report ProgressFunc
+	// pipe is read by the scanner counting objects, which closes counted
+	// once it is done
+	pipe    *io.PipeWriter
+	counted chan struct{}
+
+	// mutex also serializes calls to report, so that events are received in
+	// order
+	mutex   sync.Mutex
+	header  []byte
+	total   int
+	current int
+	bytes   int64
+}
+
+func newCountingWriter(writer io.WriteCloser, report ProgressFunc) *countingWriter {
+	reader, pipe := io.Pipe()
+	w := &countingWriter{
+		WriteCloser: writer,
+		report:      report,
+		pipe:        pipe,
+		counted:     make(chan struct{}),
+	}
+	go w.count(reader)
+	return w
+}
+
+// count scans the objects of the packfile read from [reader], reporting
+// whenever the percentage of objects received changes. The rest of the
+// packfile is discarded if it cannot be scanned, so that writes never block.
+func (w *countingWriter) count(reader *io.PipeReader) {
+	defer close(w.counted)
+	defer io.Copy(ioutil.Discard, reader)
+
+	scanner := packfile.NewScanner(reader)
+	_, objects, err := scanner.Header()
+	if err != nil {
+		return
+	}
+	for current := 1; current <= int(objects); current++ {
+		if _, err := scanner.NextObjectHeader(); err != nil {
+			return
+		}
+		if _, _, err := scanner.NextObject(ioutil.Discard); err != nil {
+			return
+		}
+
+		w.mutex.Lock()
+		previous := w.current
+		w.current = current
+		if current*100/int(objects) != previous*100/int(objects) {
+			w.report(w.event(false))
+		}
+		w.mutex.Unlock()
+	}
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+
+	w.mutex.Lock()
+	if len(w.header) < packHeaderSize {
+		missing := packHeaderSize - len(w.header)
+		if missing > n {
+			missing = n
+		}
+		w.header = append(w.header, p[:missing]...)
+		if len(w.header) == packHeaderSize && string(w.header[:4]) == "PACK" {
+			w.total = int(binary.BigEndian.Uint32(w.header[8:]))
+		}
+	}
+	w.bytes += int64(n)
+	w.report(w.event(false))
+	w.mutex.Unlock()
+
+	// The pipe only fails once the scanner is done, which does not affect
+	// the packfile itself
+	w.pipe.Write(p[:n])
+	return n, err
+}
+
+func (w *countingWriter) Close() error {
+	w.pipe.Close()
+	<-w.counted
+	err := w.WriteCloser.Close()
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err == nil {
+		w.current = w.total
+		w.report(w.event(true))
+	}
+	return err
+}
+
+// event returns the current progress. The mutex must be held.
+func (w *countingWriter) event(done bool) ProgressEvent {
+	return ProgressEvent{
+		Phase:   PhaseReceiving,
+		Current: w.current,
+		Total:   w.total,
+		Bytes:   w.bytes,
+		Done:    done,
+	}
+}
@@ -0,0 +1,154 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRemoteProgress(t *testing.T) {
+	t.Run("it should parse progress messages with a total", func(t *testing.T) {
+		event, ok := parseRemoteProgress("Counting objects:  45% (9/20)")
+		assert.True(t, ok)
+		assert.Equal(t, ProgressEvent{Phase: PhaseCounting, Current: 9, Total: 20}, event)
+
+		event, ok = parseRemoteProgress("remote: Compressing objects: 100% (3/3), done.")
+		assert.True(t, ok)
+		assert.Equal(t, ProgressEvent{Phase: PhaseCompressing, Current: 3, Total: 3, Done: true}, event)
+	})
+
+	t.Run("it should parse progress messages without a total", func(t *testing.T) {
+		event, ok := parseRemoteProgress("Enumerating objects: 20, done.")
+		assert.True(t, ok)
+		assert.Equal(t, ProgressEvent{Phase: PhaseCounting, Current: 20, Done: true}, event)
+	})
+
+	t.Run("it should ignore other messages", func(t *testing.T) {
+		_, ok := parseRemoteProgress("Total 20 (delta 2), reused 0 (delta 0), pack-reused 0")
+		assert.False(t, ok)
+	})
+}
+
+func TestProgressWriter(t *testing.T) {
+	t.Run("it should report messages split across writes", func(t *testing.T) {
+		events := []ProgressEvent{}
+		writer := newProgressWriter(func(event ProgressEvent) {
+			events = append(events, event)
+		})
+
+		writer.Write([]byte("Counting objects:  50% (1/2)\rCounting obj"))
+		writer.Write([]byte("ects: 100% (2/2), done.\n"))
+
+		assert.Equal(t, []ProgressEvent{
+			{Phase: PhaseCounting, Current: 1, Total: 2},
+			{Phase: PhaseCounting, Current: 2, Total: 2, Done: true},
+		}, events)
+	})
+}
+
+func TestProgress(t *testing.T) {
+	t.Run("it should report the received packfile while cloning", func(t *testing.T) {
+		var mutex sync.Mutex
+		events := []ProgressEvent{}
+
+		_, err := Create(context.Background(), CreateOptions{
+			RepositoryDir: t.TempDir(),
+			RemoteURL:     testRemote(t),
+			Progress: func(event ProgressEvent) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				events = append(events, event)
+			},
+		})
+		assert.NoError(t, err)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.NotEmpty(t, events)
+
+		last := events[len(events)-1]
+		assert.Equal(t, PhaseReceiving, last.Phase)
+		assert.True(t, last.Done)
+		assert.Greater(t, last.Total, 0)
+		assert.Equal(t, last.Total, last.Current)
+		assert.Greater(t, last.Bytes, int64(0))
+	})
+}
+
+func TestCountingWriter(t *testing.T) {
+	t.Run("it should report the objects received while the packfile is written", func(t *testing.T) {
+		pack := testPackfile(t, 50)
+		events := []ProgressEvent{}
+		writer := newCountingWriter(discardCloser{}, func(event ProgressEvent) {
+			events = append(events, event)
+		})
+
+		for start := 0; start < len(pack); start += 64 {
+			end := start + 64
+			if end > len(pack) {
+				end = len(pack)
+			}
+			_, err := writer.Write(pack[start:end])
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, writer.Close())
+
+		intermediate := 0
+		previous := 0
+		for _, event := range events {
+			assert.GreaterOrEqual(t, event.Current, previous)
+			previous = event.Current
+			if !event.Done && event.Current > 0 && event.Current < 50 {
+				assert.Equal(t, 50, event.Total)
+				intermediate++
+			}
+		}
+		assert.Greater(t, intermediate, 0)
+
+		last := events[len(events)-1]
+		assert.True(t, last.Done)
+		assert.Equal(t, 50, last.Current)
+		assert.Equal(t, int64(len(pack)), last.Bytes)
+	})
+}
+
+// testPackfile returns a packfile containing [count] distinct blobs
+func testPackfile(t *testing.T, count int) []byte {
+	storage := memory.NewStorage()
+	hashes := []plumbing.Hash{}
+	for index := 0; index < count; index++ {
+		object := storage.NewEncodedObject()
+		object.SetType(plumbing.BlobObject)
+		writer, err := object.Writer()
+		assert.NoError(t, err)
+		fmt.Fprintf(writer, "# template %d\n%s\n", index, bytes.Repeat([]byte{byte('a' + index%26)}, 100+index))
+		assert.NoError(t, writer.Close())
+
+		hash, err := storage.SetEncodedObject(object)
+		assert.NoError(t, err)
+		hashes = append(hashes, hash)
+	}
+
+	var pack bytes.Buffer
+	_, err := packfile.NewEncoder(&pack, storage, false).Encode(hashes, 0)
+	assert.NoError(t, err)
+	return pack.Bytes()
+}
+
+// discardCloser is an io.WriteCloser which discards what is written to it
+type discardCloser struct{}
+
+func (discardCloser) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (discardCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,34 @@
+package git
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/haroldadmin/getignore/pkg/network"
+)
+
+// InstallHTTPClient makes go-git use [httpClient] for http and https
+// remotes, such as a client created with network.NewHTTPClient. go-git
+// selects transports globally, so this affects every repository in the
+// process.
+func InstallHTTPClient(httpClient *http.Client) {
+	transport := githttp.NewClient(httpClient)
+	client.InstallProtocol("http", transport)
+	client.InstallProtocol("https", transport)
+}
+
+// describeNetworkError adds a hint to proxy and certificate errors returned
+// by go-git, which wraps them in errors that can not be unwrapped
+func describeNetworkError(err error) error {
+	var unexpected *plumbing.UnexpectedError
+	if errors.As(err, &unexpected) {
+		if described := network.DescribeError(unexpected.Err); described != unexpected.Err {
+			return described
+		}
+		return err
+	}
+	return network.DescribeError(err)
+}
@@ -0,0 +1,26 @@
+package git
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/haroldadmin/getignore/pkg/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeNetworkError(t *testing.T) {
+	t.Run("it should describe certificate errors wrapped by go-git", func(t *testing.T) {
+		err := describeNetworkError(plumbing.NewUnexpectedError(x509.UnknownAuthorityError{}))
+		assert.True(t, errors.Is(err, network.ErrCertificate))
+	})
+
+	t.Run("it should return other errors unchanged", func(t *testing.T) {
+		assert.Equal(t, git.NoErrAlreadyUpToDate, describeNetworkError(git.NoErrAlreadyUpToDate))
+
+		unexpected := plumbing.NewUnexpectedError(errors.New("connection reset"))
+		assert.Equal(t, error(unexpected), describeNetworkError(unexpected))
+	})
+}
@@ -0,0 +1,48 @@
+package gitignore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/pkg/remote"
+)
+
+// APISource creates a Source which lists the templates of a gitignore.io
+// compatible server with [client]. Templates are named after the names of
+// the API with a ".gitignore" extension, such as "go.gitignore", and each
+// template is downloaded only when it is read.
+func APISource(ctx context.Context, name string, client *remote.APIClient) (Source, error) {
+	logger := logs.CreateLogger("gitignore.api")
+
+	names, err := client.List(ctx)
+	if err != nil {
+		message := "failed to list templates"
+		logger.Errorf("%s: %v", message, err)
+		return Source{}, fmt.Errorf("%w: %s: %v", ErrInvalidSource, message, err)
+	}
+
+	index := memfs.New()
+	for _, templateName := range names {
+		file, err := index.Create(templateName + ".gitignore")
+		if err != nil {
+			return Source{}, fmt.Errorf("%w: %v", ErrInvalidSource, err)
+		}
+		file.Close()
+	}
+
+	logger.Infof("found %d templates", len(names))
+	return Source{
+		Name: name,
+		Templates: &httpSource{
+			index: &filesystemSource{filesystem: index},
+			fetch: func(ctx context.Context, filename string) ([]byte, error) {
+				templateName := strings.TrimSuffix(filepath.Base(filename), ".gitignore")
+				return client.Fetch(ctx, templateName)
+			},
+		},
+	}, nil
+}
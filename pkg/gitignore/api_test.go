@@ -0,0 +1,84 @@
+package gitignore_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/haroldadmin/getignore/pkg/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPISource(t *testing.T) {
+	files := map[string]string{
+		"/api/list": "go,node\nvisualstudiocode\n",
+		"/api/go":   "# Created by gitignore.io\n*.exe\n",
+	}
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		contents, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(contents))
+	}))
+	defer server.Close()
+
+	client, err := remote.NewAPI(remote.Options{BaseURL: server.URL, CacheDir: t.TempDir()})
+	assert.NoError(t, err)
+	source, err := gitignore.APISource(context.Background(), "gitignoreio", client)
+	assert.NoError(t, err)
+	service, err := gitignore.CreateFromSources(context.Background(), source, gitignore.RepositorySource("github", memoryRepository(t, map[string]string{"Go.gitignore": "vendor/\n"})))
+	assert.NoError(t, err)
+
+	t.Run("it should list the templates alongside those of other sources", func(t *testing.T) {
+		names := []string{}
+		for _, file := range service.GetAll() {
+			names = append(names, file.QualifiedName())
+		}
+		sort.Strings(names)
+		assert.Equal(t, []string{
+			"github:Go.gitignore",
+			"gitignoreio:go.gitignore",
+			"gitignoreio:node.gitignore",
+			"gitignoreio:visualstudiocode.gitignore",
+		}, names)
+		assert.Equal(t, 0, requests["/api/go"])
+	})
+
+	t.Run("it should find templates by their API name", func(t *testing.T) {
+		file, err := service.Get(context.Background(), "visualstudiocode")
+		assert.NoError(t, err)
+		assert.Equal(t, "gitignoreio", file.Source)
+	})
+
+	t.Run("it should search the templates", func(t *testing.T) {
+		results, err := service.Search("node")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, results)
+		assert.Equal(t, "node.gitignore", results[0].Name)
+	})
+
+	t.Run("it should download templates when they are written", func(t *testing.T) {
+		file, err := service.Get(context.Background(), "gitignoreio:go")
+		assert.NoError(t, err)
+
+		destFs := memfs.New()
+		assert.NoError(t, service.Write(context.Background(), file, destFs))
+
+		written, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer written.Close()
+		contents, err := ioutil.ReadAll(written)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), "*.exe")
+		assert.Equal(t, 1, requests["/api/go"])
+	})
+}
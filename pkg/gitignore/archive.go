@@ -0,0 +1,206 @@
+package gitignore
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/haroldadmin/getignore/internal/logs"
+)
+
+var ErrInvalidArchive = errors.New("invalid-archive")
+
+// maxArchiveEntrySize is the size above which archive entries are skipped.
+// Templates are small text files, so anything larger is not one of them.
+const maxArchiveEntrySize = 1 << 20
+
+// archiveEntry is a regular file or symlink read from an archive. The
+// contents of a symlink are its target.
+type archiveEntry struct {
+	name     string
+	contents []byte
+	symlink  bool
+}
+
+// ArchiveSource creates a Source which reads gitignore files from the .zip,
+// .tar.gz or .tgz archive at [archivePath], such as a source archive of a
+// template repository downloaded from GitHub. A single top level directory
+// containing all entries, like "gitignore-main/", is stripped.
+func ArchiveSource(name string, archivePath string) (Source, error) {
+	logger := logs.CreateLogger("gitignore.archive")
+	logger.Infof("reading archive %s", archivePath)
+
+	var entries []archiveEntry
+	var err error
+	switch lowerPath := strings.ToLower(archivePath); {
+	case strings.HasSuffix(lowerPath, ".zip"):
+		entries, err = readZip(archivePath)
+	case strings.HasSuffix(lowerPath, ".tar.gz"), strings.HasSuffix(lowerPath, ".tgz"):
+		entries, err = readTarGz(archivePath)
+	default:
+		return Source{}, fmt.Errorf("%w: %s is not a .zip, .tar.gz or .tgz file", ErrInvalidArchive, archivePath)
+	}
+	if err != nil {
+		message := fmt.Sprintf("failed to read archive %s", archivePath)
+		logger.Errorf("%s: %v", message, err)
+		return Source{}, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, message, err)
+	}
+
+	filesystem, err := extract(stripTopLevelDir(entries))
+	if err != nil {
+		message := fmt.Sprintf("failed to extract archive %s", archivePath)
+		logger.Errorf("%s: %v", message, err)
+		return Source{}, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, message, err)
+	}
+
+	logger.Infof("read %d entries from %s", len(entries), archivePath)
+	return FilesystemSource(name, filesystem), nil
+}
+
+func readZip(archivePath string) ([]archiveEntry, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	entries := []archiveEntry{}
+	for _, file := range reader.File {
+		mode := file.Mode()
+		if !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+			continue
+		}
+		if file.UncompressedSize64 > maxArchiveEntrySize {
+			continue
+		}
+
+		contents, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{
+			name:     file.Name,
+			contents: contents,
+			symlink:  mode&os.ModeSymlink != 0,
+		})
+	}
+
+	return entries, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(io.LimitReader(reader, maxArchiveEntrySize))
+}
+
+func readTarGz(archivePath string) ([]archiveEntry, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+
+	entries := []archiveEntry{}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			if header.Size > maxArchiveEntrySize {
+				continue
+			}
+			contents, err := ioutil.ReadAll(tarReader)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, archiveEntry{name: header.Name, contents: contents})
+		case tar.TypeSymlink:
+			entries = append(entries, archiveEntry{
+				name:     header.Name,
+				contents: []byte(header.Linkname),
+				symlink:  true,
+			})
+		}
+	}
+}
+
+// stripTopLevelDir removes the directory wrapping all [entries], if there is
+// one
+func stripTopLevelDir(entries []archiveEntry) []archiveEntry {
+	prefix := ""
+	for _, entry := range entries {
+		index := strings.Index(entry.name, "/")
+		if index < 0 {
+			return entries
+		}
+		if prefix == "" {
+			prefix = entry.name[:index+1]
+		}
+		if !strings.HasPrefix(entry.name, prefix) {
+			return entries
+		}
+	}
+
+	stripped := make([]archiveEntry, len(entries))
+	for index, entry := range entries {
+		entry.name = strings.TrimPrefix(entry.name, prefix)
+		stripped[index] = entry
+	}
+	return stripped
+}
+
+// extract writes [entries] to an in-memory filesystem. Entries and symlinks
+// pointing outside of the archive are rejected.
+func extract(entries []archiveEntry) (billy.Filesystem, error) {
+	filesystem := memfs.New()
+	for _, entry := range entries {
+		name := path.Clean("/" + entry.name)
+		if name == "/" || escapesRoot(entry.name) {
+			return nil, fmt.Errorf("entry %q is outside of the archive", entry.name)
+		}
+
+		if entry.symlink {
+			target := string(entry.contents)
+			if path.IsAbs(target) || escapesRoot(path.Join(path.Dir(name[1:]), target)) {
+				return nil, fmt.Errorf("symlink %q points outside of the archive", entry.name)
+			}
+			if err := filesystem.Symlink(target, name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := util.WriteFile(filesystem, name, entry.contents, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return filesystem, nil
+}
@@ -0,0 +1,160 @@
+package gitignore_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/stretchr/testify/assert"
+)
+
+// testArchiveEntry is a file of a test archive. Entries with a link are
+// symlinks to it.
+type testArchiveEntry struct {
+	name     string
+	contents string
+	link     string
+}
+
+var testArchiveEntries = []testArchiveEntry{
+	{name: "gitignore-main/Go.gitignore", contents: "*.exe\n"},
+	{name: "gitignore-main/Java.gitignore", contents: "*.class\n"},
+	{name: "gitignore-main/Kotlin.gitignore", link: "Java.gitignore"},
+	{name: "gitignore-main/Global/macOS.gitignore", contents: ".DS_Store\n"},
+	{name: "gitignore-main/README.md", contents: "# gitignore\n"},
+}
+
+func TestArchiveSource(t *testing.T) {
+	for _, extension := range []string{".zip", ".tar.gz"} {
+		extension := extension
+
+		t.Run("it should list the templates of a "+extension+" archive", func(t *testing.T) {
+			archivePath := writeTestArchive(t, extension, testArchiveEntries)
+			source, err := gitignore.ArchiveSource("archive", archivePath)
+			assert.NoError(t, err)
+
+			service, err := gitignore.CreateFromSources(context.Background(), source)
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, file := range service.GetAll() {
+				names = append(names, file.Name)
+			}
+			sort.Strings(names)
+			assert.Equal(t, []string{"Go.gitignore", "Java.gitignore", "Kotlin.gitignore", "macOS.gitignore"}, names)
+
+			file, err := service.Get(context.Background(), "macOS.gitignore")
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.Join("/", "Global", "macOS.gitignore"), file.Path)
+		})
+
+		t.Run("it should follow symlinks in a "+extension+" archive", func(t *testing.T) {
+			archivePath := writeTestArchive(t, extension, testArchiveEntries)
+			source, err := gitignore.ArchiveSource("archive", archivePath)
+			assert.NoError(t, err)
+
+			service, err := gitignore.CreateFromSources(context.Background(), source)
+			assert.NoError(t, err)
+
+			file, err := service.Get(context.Background(), "Kotlin.gitignore")
+			assert.NoError(t, err)
+
+			destFs := memfs.New()
+			assert.NoError(t, service.Write(context.Background(), file, destFs))
+			written, err := destFs.Open(".gitignore")
+			assert.NoError(t, err)
+			defer written.Close()
+			contents, err := ioutil.ReadAll(written)
+			assert.NoError(t, err)
+			assert.Contains(t, string(contents), "*.class")
+		})
+
+		t.Run("it should reject entries outside of a "+extension+" archive", func(t *testing.T) {
+			archivePath := writeTestArchive(t, extension, []testArchiveEntry{
+				{name: "gitignore-main/Go.gitignore", contents: "*.exe\n"},
+				{name: "gitignore-main/Passwd.gitignore", link: "../../../etc/passwd"},
+			})
+
+			_, err := gitignore.ArchiveSource("archive", archivePath)
+			assert.True(t, errors.Is(err, gitignore.ErrInvalidArchive))
+		})
+	}
+
+	t.Run("it should reject unsupported archive formats", func(t *testing.T) {
+		_, err := gitignore.ArchiveSource("archive", filepath.Join(t.TempDir(), "gitignore.rar"))
+		assert.True(t, errors.Is(err, gitignore.ErrInvalidArchive))
+	})
+}
+
+// writeTestArchive writes [entries] to an archive with the given extension,
+// and returns its path
+func writeTestArchive(t *testing.T, extension string, entries []testArchiveEntry) string {
+	t.Helper()
+
+	archivePath := filepath.Join(t.TempDir(), "gitignore"+extension)
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer file.Close()
+
+	switch extension {
+	case ".zip":
+		writer := zip.NewWriter(file)
+		for _, entry := range entries {
+			header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+			contents := entry.contents
+			header.SetMode(0644)
+			if entry.link != "" {
+				header.SetMode(os.ModeSymlink | 0777)
+				contents = entry.link
+			}
+			entryWriter, err := writer.CreateHeader(header)
+			if err != nil {
+				t.Fatalf("failed to add %s: %v", entry.name, err)
+			}
+			entryWriter.Write([]byte(contents))
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("failed to write zip: %v", err)
+		}
+
+	case ".tar.gz":
+		gzipWriter := gzip.NewWriter(file)
+		writer := tar.NewWriter(gzipWriter)
+		for _, entry := range entries {
+			header := &tar.Header{
+				Name:     entry.name,
+				Typeflag: tar.TypeReg,
+				Mode:     0644,
+				Size:     int64(len(entry.contents)),
+			}
+			if entry.link != "" {
+				header.Typeflag = tar.TypeSymlink
+				header.Linkname = entry.link
+				header.Size = 0
+			}
+			if err := writer.WriteHeader(header); err != nil {
+				t.Fatalf("failed to add %s: %v", entry.name, err)
+			}
+			writer.Write([]byte(entry.contents))
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("failed to write tar: %v", err)
+		}
+		if err := gzipWriter.Close(); err != nil {
+			t.Fatalf("failed to write gzip: %v", err)
+		}
+	}
+
+	return archivePath
+}
@@ -2,16 +2,18 @@ package gitignore
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/go-git/go-billy/v5"
-	"github.com/go-git/go-git/v5"
 	"github.com/haroldadmin/getignore/internal/logs"
-	"github.com/haroldadmin/getignore/pkg/fs"
+	"github.com/haroldadmin/getignore/pkg/utils"
 	"github.com/sahilm/fuzzy"
 )
 
@@ -24,27 +26,105 @@ var (
 	ErrReadFile        = errors.New("failed-to-read-file")
 	ErrCopyFile        = errors.New("failed-to-copy-file")
 	ErrFlushChanges    = errors.New("failed-to-flush-changes")
+	ErrUnknownSource   = errors.New("unknown-source")
+	ErrDuplicateSource = errors.New("duplicate-source")
+	ErrInvalidSource   = errors.New("invalid-source")
+	ErrUnknownRevision = errors.New("unknown-revision")
+	ErrNoRevisions     = errors.New("revisions-unsupported")
+	ErrAmbiguousName   = errors.New("ambiguous-name")
+	ErrNoHistory       = errors.New("history-unavailable")
 )
 
 type GitIgnoreFile struct {
 	Name string
 	Path string
+	// Category is the directory of the file relative to the root of its
+	// source, such as "Global" or "community/Golang". It is empty for files
+	// at the root.
+	Category string
+	// AliasOf is the path name of the template this file is a symlink to,
+	// such as "Global/macOS.gitignore". It is empty for regular files.
+	AliasOf string
+	// Source is the name of the Source this file belongs to
+	Source string
+	// Revision is the revision of its source to read the file from, such as
+	// a commit hash. The file is read from the current files of its source
+	// when empty.
+	Revision string
+	// Hash identifies the contents of the file, such as its git blob hash.
+	// It is empty for sources which do not provide one.
+	Hash string
+	// Description is the first paragraph of the comments the file starts
+	// with, such as "gitignore template for Drupal 8 projects". It is empty
+	// until the header has been read, and for files without one.
+	Description string
+	// Links are the URLs mentioned in the comments the file starts with,
+	// such as the documentation of the tool it is for
+	Links []string
 }
 
+// PathName returns the name of the file prefixed with its category, such as
+// "Global/macOS.gitignore"
+func (f GitIgnoreFile) PathName() string {
+	return path.Join(f.Category, f.Name)
+}
+
+// QualifiedName returns the path name of the file prefixed with its source,
+// such as "acme:Global/macOS.gitignore". Files from an unnamed source have no
+// prefix. Files read from a revision are suffixed with its abbreviated hash.
+func (f GitIgnoreFile) QualifiedName() string {
+	name := f.PathName()
+	if f.Source != "" {
+		name = f.Source + SourceSeparator + name
+	}
+	if f.Revision != "" {
+		name = name + RevisionSeparator + utils.ShortHash(f.Revision)
+	}
+	return name
+}
+
+const (
+	// SourceSeparator separates the name of a source from the name of a file
+	// in qualified names
+	SourceSeparator = ":"
+	// RevisionSeparator separates the name of a file from a branch, tag or
+	// commit to read it from, as in "Go.gitignore@v1.2"
+	RevisionSeparator = "@"
+)
+
 type GitIgnoreService interface {
-	Get(name string) (GitIgnoreFile, error)
+	// Get finds the file called [name]. Names at a revision, such as
+	// "Go.gitignore@v1.2", list the sources at that revision with [ctx].
+	Get(ctx context.Context, name string) (GitIgnoreFile, error)
 	GetAll() []GitIgnoreFile
 	Search(query string) ([]GitIgnoreFile, error)
-	Write(file GitIgnoreFile, destFs billy.Filesystem) error
-	Append(file GitIgnoreFile, destFs billy.Filesystem) error
+	// Describe returns [file] with the Description and Links read from its
+	// header. Templates which would have to be downloaded first are returned
+	// unchanged.
+	Describe(ctx context.Context, file GitIgnoreFile) (GitIgnoreFile, error)
+	// Metadata returns the blob hash and size of [file], along with the last
+	// commit which changed it. Files of sources which are not git
+	// repositories fail with ErrNoHistory.
+	Metadata(ctx context.Context, file GitIgnoreFile) (Metadata, error)
+	// Write replaces the .gitignore file in [destFs] with [file], which is
+	// downloaded with [ctx] if its source serves templates remotely
+	Write(ctx context.Context, file GitIgnoreFile, destFs billy.Filesystem) error
+	// Append adds [file] to the end of the .gitignore file in [destFs], like
+	// Write does
+	Append(ctx context.Context, file GitIgnoreFile, destFs billy.Filesystem) error
 }
 
-func Create(repository *git.Repository) (GitIgnoreService, error) {
+// CreateFromSources creates a GitIgnoreService which aggregates the
+// gitignore files of all the given sources. Sources are listed in order of
+// priority: when several sources contain a file with the same name, Get
+// returns the one from the source listed first. Listing the sources stops
+// early when [ctx] is cancelled.
+func CreateFromSources(ctx context.Context, sources ...Source) (GitIgnoreService, error) {
 	service := &gitIgnoreService{
-		repo: repository,
+		sources: sources,
 	}
 
-	err := service.initialize()
+	err := service.initialize(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -53,59 +133,264 @@ func Create(repository *git.Repository) (GitIgnoreService, error) {
 }
 
 type gitIgnoreService struct {
-	repo       *git.Repository
+	sources    []Source
 	gitIgnores []GitIgnoreFile
 }
 
-func (g *gitIgnoreService) initialize() error {
+func (g *gitIgnoreService) initialize(ctx context.Context) error {
 	logger := logs.CreateLogger("gitignore.init")
 	logger.Infof("initializing GitIgnoreService")
 
-	worktree, err := g.repo.Worktree()
+	gitIgnores := []GitIgnoreFile{}
+	seen := utils.NewSet()
+	for _, source := range g.sources {
+		if seen.Contains(source.Name) {
+			logger.Errorf("duplicate source %q", source.Name)
+			return fmt.Errorf("%w: %q", ErrDuplicateSource, source.Name)
+		}
+		seen.Add(source.Name)
+
+		files, err := readSource(ctx, source)
+		if err != nil {
+			return err
+		}
+		gitIgnores = append(gitIgnores, files...)
+	}
+
+	logger.Infof("found %d gitignore files", len(gitIgnores))
+	for _, f := range gitIgnores {
+		logger.Debugf("%s (%s)", f.QualifiedName(), f.Path)
+	}
+
+	g.gitIgnores = gitIgnores
+	return nil
+}
+
+func readSource(ctx context.Context, source Source) ([]GitIgnoreFile, error) {
+	logger := logs.CreateLogger("gitignore.init")
+
+	if source.Templates == nil {
+		logger.Errorf("invalid source %q", source.Name)
+		return nil, fmt.Errorf("%w: source %q has no templates", ErrInvalidSource, source.Name)
+	}
+
+	revision := source.Templates.Revision()
+	if revision != "" {
+		logger.Debugf("reading source %q at revision %s", source.Name, revision)
+	} else {
+		logger.Debugf("reading source %q", source.Name)
+	}
+
+	if gitIgnores, ok := readIndex(source, revision); ok {
+		logger.Debugf("using index of source %q", source.Name)
+		return gitIgnores, nil
+	}
+
+	paths, err := source.Templates.List(ctx)
 	if err != nil {
-		message := "invalid repository worktree"
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		message := "failed to list files of gitignore repository"
 		logger.Errorf("%s: %v", message, err)
-		return ErrInvalidWorktree
+		return nil, ErrReadRepoDir
 	}
 
-	logger.Debugf("reading repo recursively for .gitignore files")
-	repoFilesystem := worktree.Filesystem
-	files, err := fs.ReadDirRecursively(repoFilesystem)
+	gitIgnores := gitIgnoresIn(source.Name, paths)
+	if links, ok := source.Templates.(LinkSource); ok {
+		gitIgnores = resolveAliases(links, gitIgnores)
+	}
+	if hashes, ok := source.Templates.(HashSource); ok {
+		gitIgnores = addHashes(hashes, gitIgnores)
+	}
+	// Indexed files are only read once per revision, so their headers are
+	// read right away and stored along with them
+	if isIndexed(source, revision) {
+		gitIgnores = addHeaders(ctx, source.Templates, gitIgnores)
+	}
+
+	writeIndex(source, revision, gitIgnores)
+	return gitIgnores, nil
+}
+
+// addHashes sets the Hash of the files in [gitIgnores]. Files which can not
+// be hashed are kept without one.
+func addHashes(hashes HashSource, gitIgnores []GitIgnoreFile) []GitIgnoreFile {
+	logger := logs.CreateLogger("gitignore.init")
+
+	for index, file := range gitIgnores {
+		hash, err := hashes.Hash(file.Path)
+		if err != nil {
+			logger.Warnf("failed to hash %s: %v", file.QualifiedName(), err)
+			continue
+		}
+		gitIgnores[index].Hash = hash
+	}
+	return gitIgnores
+}
+
+// addHeaders sets the Description and Links of the files in [gitIgnores].
+// Files which can not be read are kept without them.
+func addHeaders(ctx context.Context, templates TemplateSource, gitIgnores []GitIgnoreFile) []GitIgnoreFile {
+	logger := logs.CreateLogger("gitignore.init")
+
+	for index, file := range gitIgnores {
+		described, err := readHeader(ctx, templates, file)
+		if err != nil {
+			logger.Warnf("failed to read header of %s: %v", file.QualifiedName(), err)
+			continue
+		}
+		gitIgnores[index] = described
+	}
+	return gitIgnores
+}
+
+// readHeader returns [file] with the Description and Links in its header,
+// read from [templates]
+func readHeader(ctx context.Context, templates TemplateSource, file GitIgnoreFile) (GitIgnoreFile, error) {
+	reader, err := templates.Open(ctx, file.Path)
 	if err != nil {
-		message := "failed to read dir of gitignore repository"
-		logger.Errorf("%s: %v", message, err)
-		return ErrReadRepoDir
+		return file, err
+	}
+	defer reader.Close()
+
+	description, links, err := parseHeader(reader)
+	if err != nil {
+		return file, err
+	}
+	file.Description = description
+	file.Links = links
+	return file, nil
+}
+
+// resolveAliases marks the files in [gitIgnores] which are symlinks as
+// aliases of their targets. Links which point outside of their source or to
+// missing files are left out.
+func resolveAliases(links LinkSource, gitIgnores []GitIgnoreFile) []GitIgnoreFile {
+	logger := logs.CreateLogger("gitignore.init")
+
+	resolved := make([]GitIgnoreFile, 0, len(gitIgnores))
+	for _, file := range gitIgnores {
+		target, err := links.Link(file.Path)
+		if err != nil {
+			logger.Warnf("skipping %s: %v", file.QualifiedName(), err)
+			continue
+		}
+
+		if target != file.Path {
+			file.AliasOf = strings.TrimPrefix(filepath.ToSlash(target), "/")
+			logger.Debugf("%s is an alias of %s", file.QualifiedName(), file.AliasOf)
+		}
+		resolved = append(resolved, file)
 	}
+	return resolved
+}
 
-	gitIgnores := make([]GitIgnoreFile, 0, len(files))
-	for _, file := range files {
-		ext := filepath.Ext(file.Path)
-		if ext != ".gitignore" {
+// gitIgnoresIn returns the gitignore files among [paths] of [sourceName]
+func gitIgnoresIn(sourceName string, paths []string) []GitIgnoreFile {
+	gitIgnores := make([]GitIgnoreFile, 0, len(paths))
+	for _, filePath := range paths {
+		if filepath.Ext(filePath) != ".gitignore" {
 			continue
 		}
 
 		gitIgnores = append(gitIgnores, GitIgnoreFile{
-			Name: file.Name,
-			Path: file.Path,
+			Name:     filepath.Base(filePath),
+			Path:     filePath,
+			Category: category(filePath),
+			Source:   sourceName,
 		})
 	}
+	return gitIgnores
+}
 
-	logger.Infof("found %d gitignore files", len(gitIgnores))
-	for _, f := range gitIgnores {
-		logger.Debugf("%s (%s)", f.Name, f.Path)
+// category returns the directory of [filePath] relative to the root of its
+// source, with slashes
+func category(filePath string) string {
+	dir := strings.TrimPrefix(filepath.ToSlash(filepath.Dir(filePath)), "/")
+	if dir == "." {
+		return ""
 	}
+	return dir
+}
 
-	g.gitIgnores = gitIgnores
-	return nil
+// splitName splits a possibly qualified name such as "acme:Go.gitignore"
+// into its source and file name. The source is empty for unqualified names.
+func splitName(name string) (source string, fileName string, qualified bool) {
+	index := strings.Index(name, SourceSeparator)
+	if index < 0 {
+		return "", name, false
+	}
+	return name[:index], name[index+len(SourceSeparator):], true
 }
 
-func (g *gitIgnoreService) Get(name string) (GitIgnoreFile, error) {
+func (g *gitIgnoreService) hasSource(name string) bool {
+	_, err := g.source(name)
+	return err == nil
+}
+
+func (g *gitIgnoreService) source(name string) (Source, error) {
+	for _, source := range g.sources {
+		if source.Name == name {
+			return source, nil
+		}
+	}
+
+	return Source{}, fmt.Errorf("%w: %q", ErrUnknownSource, name)
+}
+
+func (g *gitIgnoreService) Get(ctx context.Context, name string) (GitIgnoreFile, error) {
 	logger := logs.CreateLogger("gitignore.get")
 	logger.Infof("getting file %q", name)
 
-	for _, gitignore := range g.gitIgnores {
-		if gitignore.Name == name {
-			return gitignore, nil
+	name, revision := splitRevision(name)
+	sourceName, _, qualified := splitName(name)
+	if qualified && !g.hasSource(sourceName) {
+		logger.Infof("unknown source %q", sourceName)
+		return GitIgnoreFile{}, fmt.Errorf("%w: %q", ErrUnknownSource, sourceName)
+	}
+
+	if revision != "" {
+		return g.getAtRevision(ctx, name, revision)
+	}
+	return findFile(g.gitIgnores, name)
+}
+
+// findFile returns the file of [files] called [name], which may be qualified
+// with a source name. Files are in order of priority, and only the files of
+// the first source with a match are considered. Names without an extension,
+// such as "go" as used by gitignore.io, match templates with a ".gitignore"
+// extension when nothing matches exactly.
+func findFile(files []GitIgnoreFile, name string) (GitIgnoreFile, error) {
+	logger := logs.CreateLogger("gitignore.get")
+	sourceName, fileName, qualified := splitName(name)
+
+	candidates := []string{fileName}
+	if path.Ext(fileName) == "" {
+		candidates = append(candidates, fileName+".gitignore")
+	}
+
+	for _, candidate := range candidates {
+		matches := []GitIgnoreFile{}
+		for _, gitignore := range files {
+			if qualified && gitignore.Source != sourceName {
+				continue
+			}
+			// Only the source with the highest priority is considered
+			if len(matches) > 0 && gitignore.Source != matches[0].Source {
+				break
+			}
+			if matchesName(gitignore, candidate) {
+				matches = append(matches, gitignore)
+			}
+		}
+
+		if len(matches) > 1 {
+			logger.Infof("%s matches %d files", name, len(matches))
+			return GitIgnoreFile{}, ambiguousNameError(name, matches)
+		}
+		if len(matches) == 1 {
+			return matches[0], nil
 		}
 	}
 
@@ -113,6 +398,116 @@ func (g *gitIgnoreService) Get(name string) (GitIgnoreFile, error) {
 	return GitIgnoreFile{}, ErrNotFound
 }
 
+// matchesName reports whether [file] is called [name]. Names may be prefixed
+// with the category of the file, as in "Global/macOS.gitignore".
+func matchesName(file GitIgnoreFile, name string) bool {
+	if strings.Contains(name, "/") {
+		return file.PathName() == strings.Trim(name, "/")
+	}
+	return file.Name == name
+}
+
+// ambiguousNameError lists the files matched by [name], so that one of them
+// can be picked by its path name
+func ambiguousNameError(name string, matches []GitIgnoreFile) error {
+	candidates := make([]string, len(matches))
+	for index, file := range matches {
+		candidates[index] = file.PathName()
+	}
+	return fmt.Errorf(
+		"%w: %q matches %s; use one of them instead",
+		ErrAmbiguousName,
+		name,
+		strings.Join(candidates, ", "),
+	)
+}
+
+// getAtRevision finds the file called [name] at [revision] of the sources,
+// matching names like Get does, without changing the files the service
+// reads otherwise. Files deleted since that revision are found too.
+func (g *gitIgnoreService) getAtRevision(ctx context.Context, name string, revision string) (GitIgnoreFile, error) {
+	logger := logs.CreateLogger("gitignore.get")
+	sourceName, _, qualified := splitName(name)
+
+	files := []GitIgnoreFile{}
+	var lastErr error = ErrNotFound
+	for _, source := range g.sources {
+		if qualified && source.Name != sourceName {
+			continue
+		}
+		revisions, ok := source.Templates.(RevisionSource)
+		if !ok {
+			if qualified {
+				return GitIgnoreFile{}, fmt.Errorf("%w: source %q has no revisions", ErrNoRevisions, source.Name)
+			}
+			continue
+		}
+
+		templates, err := revisions.At(revision)
+		if err != nil {
+			logger.Infof("%s not found in source %q", revision, source.Name)
+			lastErr = err
+			continue
+		}
+
+		paths, err := templates.List(ctx)
+		if err != nil {
+			lastErr = fmt.Errorf("%w: %v", ErrUnknownRevision, err)
+			continue
+		}
+
+		for _, file := range gitIgnoresIn(source.Name, paths) {
+			file.Revision = templates.Revision()
+			files = append(files, file)
+		}
+	}
+
+	file, err := findFile(files, name)
+	if errors.Is(err, ErrNotFound) {
+		logger.Infof("%s not found at %s", name, revision)
+		return GitIgnoreFile{}, lastErr
+	}
+	return file, err
+}
+
+// splitRevision splits a name such as "Go.gitignore@v1.2" into the file name
+// and the revision. The revision is empty if there is none.
+func splitRevision(name string) (string, string) {
+	index := strings.LastIndex(name, RevisionSeparator)
+	if index < 0 {
+		return name, ""
+	}
+	return name[:index], name[index+len(RevisionSeparator):]
+}
+
+// open opens the contents of [file] from its source, at file.Revision if set
+func (g *gitIgnoreService) open(ctx context.Context, file GitIgnoreFile) (io.ReadCloser, error) {
+	source, err := g.source(file.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := templatesAt(source, file.Revision)
+	if err != nil {
+		return nil, err
+	}
+	return templates.Open(ctx, file.Path)
+}
+
+// templatesAt returns the files of [source] at [revision], or its current
+// files when [revision] is empty
+func templatesAt(source Source, revision string) (TemplateSource, error) {
+	if revision == "" {
+		return source.Templates, nil
+	}
+
+	revisions, ok := source.Templates.(RevisionSource)
+	if !ok {
+		return nil, fmt.Errorf("%w: source %q has no revisions", ErrNoRevisions, source.Name)
+	}
+	return revisions.At(revision)
+}
+
 func (g *gitIgnoreService) GetAll() []GitIgnoreFile {
 	logger := logs.CreateLogger("gitignore.getall")
 	logger.Infof("getting all gitignore files")
@@ -127,8 +522,24 @@ func (g *gitIgnoreService) Search(query string) ([]GitIgnoreFile, error) {
 	logger := logs.CreateLogger("gitignore.search")
 	logger.Infof("searching gitignore files for %q", query)
 
-	var gitIgnoresDataSource GitIgnores = g.gitIgnores
-	matches := fuzzy.FindFrom(query, gitIgnoresDataSource)
+	candidates := g.gitIgnores
+	sourceName, fileQuery, qualified := splitName(query)
+	if qualified {
+		if !g.hasSource(sourceName) {
+			logger.Infof("unknown source %q", sourceName)
+			return nil, fmt.Errorf("%w: %q", ErrUnknownSource, sourceName)
+		}
+
+		candidates = make([]GitIgnoreFile, 0, len(g.gitIgnores))
+		for _, gitignore := range g.gitIgnores {
+			if gitignore.Source == sourceName {
+				candidates = append(candidates, gitignore)
+			}
+		}
+	}
+
+	var gitIgnoresDataSource GitIgnores = candidates
+	matches := fuzzy.FindFrom(fileQuery, gitIgnoresDataSource)
 
 	if matches.Len() == 0 {
 		logger.Infof("found no matches for %q", query)
@@ -138,31 +549,58 @@ func (g *gitIgnoreService) Search(query string) ([]GitIgnoreFile, error) {
 	logger.Infof("found %d matches", matches.Len())
 	results := make([]GitIgnoreFile, matches.Len())
 	for index, match := range matches {
-		results[index] = g.gitIgnores[match.Index]
+		results[index] = candidates[match.Index]
 	}
 
 	return results, nil
 }
 
-func (g *gitIgnoreService) Write(file GitIgnoreFile, destFs billy.Filesystem) error {
-	logger := logs.CreateLogger("gitignore.write")
-	worktree, err := g.repo.Worktree()
+func (g *gitIgnoreService) Describe(ctx context.Context, file GitIgnoreFile) (GitIgnoreFile, error) {
+	if file.Description != "" || len(file.Links) > 0 {
+		return file, nil
+	}
+
+	source, err := g.source(file.Source)
 	if err != nil {
-		message := "invalid worktree"
-		logger.Infof("%s: %v", message, err)
-		return ErrInvalidWorktree
+		return file, err
+	}
+	if isDownloaded(source.Templates) {
+		return file, nil
 	}
 
-	repoFs := worktree.Filesystem
-	destFile, err := destFs.Create(".gitignore")
+	templates, err := templatesAt(source, file.Revision)
 	if err != nil {
-		message := "failed to create/truncate .gitignore file"
-		logger.Errorf("%s: %v", message, err)
-		return ErrInvalidFile
+		return file, err
 	}
-	defer destFile.Close()
+	return readHeader(ctx, templates, file)
+}
+
+func (g *gitIgnoreService) Metadata(ctx context.Context, file GitIgnoreFile) (Metadata, error) {
+	logger := logs.CreateLogger("gitignore.metadata")
 
-	srcFile, err := repoFs.Open(file.Path)
+	source, err := g.source(file.Source)
+	if err != nil {
+		return Metadata{}, err
+	}
+	templates, err := templatesAt(source, file.Revision)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	metadata, ok := templates.(MetadataSource)
+	if !ok {
+		logger.Infof("source %q has no history", source.Name)
+		return Metadata{}, fmt.Errorf("%w: source %q is not a git repository", ErrNoHistory, source.Name)
+	}
+	return metadata.Metadata(ctx, file.Path)
+}
+
+func (g *gitIgnoreService) Write(ctx context.Context, file GitIgnoreFile, destFs billy.Filesystem) error {
+	logger := logs.CreateLogger("gitignore.write")
+
+	// The source is opened first so that an existing .gitignore is not
+	// truncated when the template can not be read, such as an unknown @rev
+	srcFile, err := g.open(ctx, file)
 	if err != nil {
 		message := "failed to open source .gitignore file"
 		logger.Errorf("%s: %v", message, err)
@@ -170,6 +608,14 @@ func (g *gitIgnoreService) Write(file GitIgnoreFile, destFs billy.Filesystem) er
 	}
 	defer srcFile.Close()
 
+	destFile, err := destFs.Create(".gitignore")
+	if err != nil {
+		message := "failed to create/truncate .gitignore file"
+		logger.Errorf("%s: %v", message, err)
+		return ErrInvalidFile
+	}
+	defer destFile.Close()
+
 	destWriter := bufio.NewWriter(destFile)
 	destWriter.WriteString("\n\n")
 	destWriter.WriteString("# ----- Contents written by getignore -----")
@@ -182,7 +628,7 @@ func (g *gitIgnoreService) Write(file GitIgnoreFile, destFs billy.Filesystem) er
 
 	bytesWritten, err := io.Copy(destFile, srcFile)
 	if err != nil {
-		message := fmt.Sprintf("failed to copy from %q to %q", srcFile.Name(), destFile.Name())
+		message := fmt.Sprintf("failed to copy from %q to %q", file.Path, destFile.Name())
 		logger.Errorf("%s: %v", message, err)
 		return ErrCopyFile
 	}
@@ -191,19 +637,12 @@ func (g *gitIgnoreService) Write(file GitIgnoreFile, destFs billy.Filesystem) er
 	return nil
 }
 
-func (g *gitIgnoreService) Append(file GitIgnoreFile, destFs billy.Filesystem) error {
+func (g *gitIgnoreService) Append(ctx context.Context, file GitIgnoreFile, destFs billy.Filesystem) error {
 	logger := logs.CreateLogger("gitignore.append")
-	worktree, err := g.repo.Worktree()
-	if err != nil {
-		message := "invalid worktree"
-		logger.Infof("%s: %v", message, err)
-		return ErrInvalidWorktree
-	}
-
-	_, err = destFs.Stat(".gitignore")
+	_, err := destFs.Stat(".gitignore")
 	if err != nil {
 		if os.IsNotExist(err) {
-			return g.Write(file, destFs)
+			return g.Write(ctx, file, destFs)
 		} else {
 			message := "failed to check if .gitignore already exists"
 			logger.Errorf("%s: %v", message, err)
@@ -219,8 +658,7 @@ func (g *gitIgnoreService) Append(file GitIgnoreFile, destFs billy.Filesystem) e
 	}
 	defer destFile.Close()
 
-	repoFs := worktree.Filesystem
-	srcFile, err := repoFs.Open(file.Path)
+	srcFile, err := g.open(ctx, file)
 	if err != nil {
 		message := "failed to open source .gitignore file"
 		logger.Errorf("%s: %v", message, err)
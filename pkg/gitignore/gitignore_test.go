@@ -1,16 +1,21 @@
 package gitignore_test
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/haroldadmin/getignore/pkg/gitignore"
@@ -21,21 +26,21 @@ func TestGitignoreService(t *testing.T) {
 	t.Run("Get", func(t *testing.T) {
 		t.Run("it should return an error if given file is not found", func(t *testing.T) {
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
-			_, err = service.Get("test.gitignore")
+			_, err = service.Get(context.Background(), "test.gitignore")
 			assert.Error(t, err)
 			assert.True(t, errors.Is(err, gitignore.ErrNotFound))
 		})
 
 		t.Run("it should not return an error if the given file is found", func(t *testing.T) {
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			fileName := "Go.gitignore"
-			file, err := service.Get(fileName)
+			file, err := service.Get(context.Background(), fileName)
 
 			assert.NoError(t, err)
 			assert.Equal(t, fileName, file.Name)
@@ -43,17 +48,17 @@ func TestGitignoreService(t *testing.T) {
 
 		t.Run("it should be case sensitive with file names", func(t *testing.T) {
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			fileName := "Go.gitignore"
-			file, err := service.Get(fileName)
+			file, err := service.Get(context.Background(), fileName)
 
 			assert.NoError(t, err)
 			assert.Equal(t, fileName, file.Name)
 
 			fileName = strings.ToLower(fileName)
-			file, err = service.Get(fileName)
+			file, err = service.Get(context.Background(), fileName)
 			assert.Error(t, err)
 			assert.True(t, errors.Is(err, gitignore.ErrNotFound))
 		})
@@ -62,7 +67,7 @@ func TestGitignoreService(t *testing.T) {
 	t.Run("GetAll", func(t *testing.T) {
 		t.Run("it should return all discovered gitignore files", func(t *testing.T) {
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			all := service.GetAll()
@@ -71,7 +76,7 @@ func TestGitignoreService(t *testing.T) {
 
 		t.Run("it should return empty list if there are no gitignore files", func(t *testing.T) {
 			repo := emptyTestRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			all := service.GetAll()
@@ -82,7 +87,7 @@ func TestGitignoreService(t *testing.T) {
 	t.Run("Search", func(t *testing.T) {
 		t.Run("it should return list of matches for a query", func(t *testing.T) {
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			matches, err := service.Search("go")
@@ -101,7 +106,7 @@ func TestGitignoreService(t *testing.T) {
 
 		t.Run("it should return an error if no matches are found", func(t *testing.T) {
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			_, err = service.Search("golang")
@@ -115,11 +120,11 @@ func TestGitignoreService(t *testing.T) {
 			repo := testRepository(t)
 			destFs := memfs.New()
 
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			gitIgnoreFile := service.GetAll()[0]
-			err = service.Write(gitIgnoreFile, destFs)
+			err = service.Write(context.Background(), gitIgnoreFile, destFs)
 			assert.NoError(t, err)
 
 			files, err := destFs.ReadDir("/")
@@ -139,11 +144,11 @@ func TestGitignoreService(t *testing.T) {
 			f.Write([]byte("test-data"))
 
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			gitIgnoreFile := service.GetAll()[0]
-			err = service.Write(gitIgnoreFile, destFs)
+			err = service.Write(context.Background(), gitIgnoreFile, destFs)
 			assert.NoError(t, err)
 
 			contents, err := ioutil.ReadAll(f)
@@ -156,12 +161,12 @@ func TestGitignoreService(t *testing.T) {
 		t.Run("it should not return an error if there is no existing gitignore file", func(t *testing.T) {
 			destFs := memfs.New()
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			gitIgnoreFile := service.GetAll()[0]
 
-			err = service.Append(gitIgnoreFile, destFs)
+			err = service.Append(context.Background(), gitIgnoreFile, destFs)
 			assert.NoError(t, err)
 		})
 
@@ -176,11 +181,11 @@ func TestGitignoreService(t *testing.T) {
 			t.Log(string(c))
 
 			repo := testRepository(t)
-			service, err := gitignore.Create(repo)
+			service, err := gitignore.Create(context.Background(), repo)
 			assert.NoError(t, err)
 
 			gitIgnoreFile := service.GetAll()[0]
-			err = service.Append(gitIgnoreFile, destFs)
+			err = service.Append(context.Background(), gitIgnoreFile, destFs)
 			assert.NoError(t, err)
 
 			f.Seek(0, 0)
@@ -194,6 +199,365 @@ func TestGitignoreService(t *testing.T) {
 	})
 }
 
+func TestCategories(t *testing.T) {
+	repo := memoryRepository(t, map[string]string{
+		"Go.gitignore":                     "bin/\n",
+		"Global/macOS.gitignore":           ".DS_Store\n",
+		"community/Golang/Hugo.gitignore":  "public/\n",
+		"community/Python/Tools.gitignore": "python-tools\n",
+		"community/Ruby/Tools.gitignore":   "ruby-tools\n",
+	})
+	service, err := gitignore.Create(context.Background(), repo)
+	assert.NoError(t, err)
+
+	t.Run("it should keep the category of files in subdirectories", func(t *testing.T) {
+		file, err := service.Get(context.Background(), "Hugo.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "community/Golang", file.Category)
+		assert.Equal(t, "community/Golang/Hugo.gitignore", file.PathName())
+
+		file, err = service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "", file.Category)
+		assert.Equal(t, "Go.gitignore", file.PathName())
+	})
+
+	t.Run("it should find files by their path name", func(t *testing.T) {
+		for _, name := range []string{"Global/macOS", "Global/macOS.gitignore", "/Global/macOS.gitignore"} {
+			file, err := service.Get(context.Background(), name)
+			assert.NoError(t, err, name)
+			assert.Equal(t, "macOS.gitignore", file.Name, name)
+		}
+
+		_, err := service.Get(context.Background(), "community/macOS")
+		assert.True(t, errors.Is(err, gitignore.ErrNotFound))
+	})
+
+	t.Run("it should return an error listing the files matching an ambiguous name", func(t *testing.T) {
+		_, err := service.Get(context.Background(), "Tools")
+		assert.True(t, errors.Is(err, gitignore.ErrAmbiguousName))
+		assert.Contains(t, err.Error(), "community/Python/Tools.gitignore")
+		assert.Contains(t, err.Error(), "community/Ruby/Tools.gitignore")
+
+		file, err := service.Get(context.Background(), "community/Ruby/Tools")
+		assert.NoError(t, err)
+		assert.Equal(t, "community/Ruby", file.Category)
+	})
+
+	t.Run("it should prefer sources over ambiguity in later sources", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.RepositorySource("acme", memoryRepository(t, map[string]string{"Tools.gitignore": "acme\n"})),
+			gitignore.RepositorySource("github", repo),
+		)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Tools.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "acme", file.Source)
+
+		_, err = service.Get(context.Background(), "github:Tools.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrAmbiguousName))
+	})
+}
+
+func TestCreateFromSources(t *testing.T) {
+	goRules := "# acme rules\nbin/\n"
+
+	t.Run("it should prefer the source listed first for unqualified names", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.RepositorySource("acme", memoryRepository(t, map[string]string{"Go.gitignore": goRules})),
+			gitignore.RepositorySource("github", testRepository(t)),
+		)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "acme", file.Source)
+		assert.Equal(t, "acme:Go.gitignore", file.QualifiedName())
+	})
+
+	t.Run("it should pick the source of a qualified name", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.RepositorySource("acme", memoryRepository(t, map[string]string{"Go.gitignore": goRules})),
+			gitignore.RepositorySource("github", testRepository(t)),
+		)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "github:Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "github", file.Source)
+
+		_, err = service.Get(context.Background(), "acme:Node.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrNotFound))
+	})
+
+	t.Run("it should return an error for unknown sources", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.RepositorySource("github", testRepository(t)),
+		)
+		assert.NoError(t, err)
+
+		_, err = service.Get(context.Background(), "vendor:Go.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrUnknownSource))
+
+		_, err = service.Search("vendor:go")
+		assert.True(t, errors.Is(err, gitignore.ErrUnknownSource))
+	})
+
+	t.Run("it should reject duplicate source names", func(t *testing.T) {
+		_, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.RepositorySource("github", testRepository(t)),
+			gitignore.RepositorySource("github", emptyTestRepository(t)),
+		)
+		assert.True(t, errors.Is(err, gitignore.ErrDuplicateSource))
+	})
+
+	t.Run("it should search all sources unless the query is qualified", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.RepositorySource("acme", memoryRepository(t, map[string]string{"Go.gitignore": goRules})),
+			gitignore.RepositorySource("github", testRepository(t)),
+		)
+		assert.NoError(t, err)
+
+		matches, err := service.Search("Go.gitignore")
+		assert.NoError(t, err)
+		sourcesFound := map[string]bool{}
+		for _, match := range matches {
+			if match.Name == "Go.gitignore" {
+				sourcesFound[match.Source] = true
+			}
+		}
+		assert.True(t, sourcesFound["acme"])
+		assert.True(t, sourcesFound["github"])
+
+		matches, err = service.Search("acme:go")
+		assert.NoError(t, err)
+		for _, match := range matches {
+			assert.Equal(t, "acme", match.Source)
+		}
+	})
+
+	t.Run("it should write contents from the source of the file", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.RepositorySource("github", testRepository(t)),
+			gitignore.RepositorySource("acme", memoryRepository(t, map[string]string{"Go.gitignore": goRules})),
+		)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "acme:Go.gitignore")
+		assert.NoError(t, err)
+
+		destFs := memfs.New()
+		err = service.Write(context.Background(), file, destFs)
+		assert.NoError(t, err)
+
+		f, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer f.Close()
+
+		contents, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), goRules)
+	})
+}
+
+func TestDirSource(t *testing.T) {
+	t.Run("it should read gitignore files from a plain directory", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.DirSource("local", filepath.Join("testdata", "gitignore")),
+		)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "local:Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Go.gitignore", file.Name)
+	})
+
+	t.Run("it should write and append gitignore files from a plain directory", func(t *testing.T) {
+		dir := t.TempDir()
+		err := ioutil.WriteFile(filepath.Join(dir, "Team.gitignore"), []byte("team-rules\n"), 0644)
+		assert.NoError(t, err)
+
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.DirSource("team", dir))
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Team.gitignore")
+		assert.NoError(t, err)
+
+		destFs := memfs.New()
+		assert.NoError(t, service.Write(context.Background(), file, destFs))
+		assert.NoError(t, service.Append(context.Background(), file, destFs))
+
+		f, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer f.Close()
+
+		contents, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(contents), "team-rules"))
+	})
+}
+
+func TestGetAtRevision(t *testing.T) {
+	readWritten := func(t *testing.T, service gitignore.GitIgnoreService, file gitignore.GitIgnoreFile) string {
+		destFs := memfs.New()
+		assert.NoError(t, service.Write(context.Background(), file, destFs))
+
+		f, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer f.Close()
+
+		contents, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		return string(contents)
+	}
+
+	t.Run("it should read a file as it was at a tag", func(t *testing.T) {
+		repo, _ := historyRepository(t)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go.gitignore@v1")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, file.Revision)
+		assert.Contains(t, readWritten(t, service, file), "go-v1")
+
+		current, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Contains(t, readWritten(t, service, current), "go-v2")
+	})
+
+	t.Run("it should read a file at an abbreviated commit hash", func(t *testing.T) {
+		repo, first := historyRepository(t)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go.gitignore@"+first.String()[:7])
+		assert.NoError(t, err)
+		assert.Equal(t, first.String(), file.Revision)
+		assert.Equal(t, "Go.gitignore@"+first.String()[:7], file.QualifiedName())
+	})
+
+	t.Run("it should find files deleted since the revision", func(t *testing.T) {
+		repo, _ := historyRepository(t)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		_, err = service.Get(context.Background(), "Old.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrNotFound))
+
+		file, err := service.Get(context.Background(), "Old.gitignore@v1")
+		assert.NoError(t, err)
+		assert.Contains(t, readWritten(t, service, file), "old-rules")
+	})
+
+	t.Run("it should match names without an extension at a revision", func(t *testing.T) {
+		repo, first := historyRepository(t)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go@v1")
+		assert.NoError(t, err)
+		assert.Equal(t, "Go.gitignore", file.Name)
+		assert.Equal(t, first.String(), file.Revision)
+	})
+
+	t.Run("it should return an error for ambiguous names at a revision", func(t *testing.T) {
+		repo := committedRepository(t, map[string]string{
+			"Go.gitignore":        "go\n",
+			"Global/Go.gitignore": "global go\n",
+		})
+		head, err := repo.Head()
+		assert.NoError(t, err)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		_, err = service.Get(context.Background(), "Go.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrAmbiguousName))
+		_, err = service.Get(context.Background(), "Go.gitignore@"+head.Hash().String())
+		assert.True(t, errors.Is(err, gitignore.ErrAmbiguousName))
+
+		file, err := service.Get(context.Background(), "Global/Go@"+head.Hash().String())
+		assert.NoError(t, err)
+		assert.Equal(t, "Global/Go.gitignore", file.PathName())
+	})
+
+	t.Run("it should return an error for unknown revisions", func(t *testing.T) {
+		repo, _ := historyRepository(t)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		_, err = service.Get(context.Background(), "Go.gitignore@v9")
+		assert.True(t, errors.Is(err, gitignore.ErrUnknownRevision))
+	})
+
+	t.Run("it should leave an existing .gitignore intact if the revision can not be read", func(t *testing.T) {
+		repo, _ := historyRepository(t)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go.gitignore@v1")
+		assert.NoError(t, err)
+		file.Revision = "does-not-exist"
+
+		destFs := memfs.New()
+		assert.NoError(t, util.WriteFile(destFs, ".gitignore", []byte("existing\n"), 0644))
+
+		err = service.Write(context.Background(), file, destFs)
+		assert.True(t, errors.Is(err, gitignore.ErrInvalidFile))
+
+		f, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer f.Close()
+		contents, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, "existing\n", string(contents))
+	})
+
+	t.Run("it should return an error for revisions of plain directories", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(),
+			gitignore.DirSource("local", filepath.Join("testdata", "gitignore")),
+		)
+		assert.NoError(t, err)
+
+		_, err = service.Get(context.Background(), "local:Go.gitignore@v1")
+		assert.True(t, errors.Is(err, gitignore.ErrNoRevisions))
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Run("it should read files from the commit checked out when created", func(t *testing.T) {
+		repo, _ := historyRepository(t)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		// Simulate another process updating the worktree
+		worktree, err := repo.Worktree()
+		assert.NoError(t, err)
+		err = util.WriteFile(worktree.Filesystem, "Go.gitignore", []byte("updated\n"), 0644)
+		assert.NoError(t, err)
+		err = util.WriteFile(worktree.Filesystem, "New.gitignore", []byte("new\n"), 0644)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Empty(t, file.Revision)
+
+		destFs := memfs.New()
+		assert.NoError(t, service.Write(context.Background(), file, destFs))
+		f, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer f.Close()
+		contents, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), "go-v2")
+		assert.NotContains(t, string(contents), "updated")
+
+		_, err = service.Get(context.Background(), "New.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrNotFound))
+	})
+}
+
 func testRepository(t *testing.T) *git.Repository {
 	t.Helper()
 
@@ -219,3 +583,98 @@ func emptyTestRepository(t *testing.T) *git.Repository {
 
 	return repo
 }
+
+// memoryRepository creates an in-memory repository whose worktree contains
+// the given files
+func memoryRepository(t *testing.T, files map[string]string) *git.Repository {
+	t.Helper()
+
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatalf("failed to create memory test repo: %v", err)
+	}
+	writeFiles(t, repo, files)
+
+	return repo
+}
+
+// committedRepository creates an in-memory repository with a single commit
+// containing the given files
+func committedRepository(t *testing.T, files map[string]string) *git.Repository {
+	t.Helper()
+
+	repo := memoryRepository(t, files)
+	commitAll(t, repo, "Add templates")
+	return repo
+}
+
+// historyRepository creates an in-memory repository with two commits. The
+// first one is tagged v1 and contains Go.gitignore and Old.gitignore, the
+// second one changes Go.gitignore and deletes Old.gitignore. It returns the
+// repository and the hash of the first commit.
+func historyRepository(t *testing.T) (*git.Repository, plumbing.Hash) {
+	t.Helper()
+
+	repo := memoryRepository(t, map[string]string{
+		"Go.gitignore":  "go-v1\n",
+		"Old.gitignore": "old-rules\n",
+	})
+	first := commitAll(t, repo, "First")
+	if _, err := repo.CreateTag("v1", first, nil); err != nil {
+		t.Fatalf("failed to tag: %v", err)
+	}
+
+	writeFiles(t, repo, map[string]string{"Go.gitignore": "go-v2\n"})
+	worktree := testWorktree(t, repo)
+	if err := worktree.Filesystem.Remove("Old.gitignore"); err != nil {
+		t.Fatalf("failed to remove Old.gitignore: %v", err)
+	}
+	commitAll(t, repo, "Second")
+
+	return repo, first
+}
+
+// writeFiles writes [files] to the worktree of [repo]
+func writeFiles(t *testing.T, repo *git.Repository, files map[string]string) {
+	t.Helper()
+
+	worktree := testWorktree(t, repo)
+	for name, contents := range files {
+		err := util.WriteFile(worktree.Filesystem, name, []byte(contents), 0644)
+		if err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// commitAll stages every change in the worktree of [repo] and commits it
+func commitAll(t *testing.T, repo *git.Repository, message string) plumbing.Hash {
+	t.Helper()
+
+	worktree := testWorktree(t, repo)
+	if _, err := worktree.Add("."); err != nil {
+		t.Fatalf("failed to stage files: %v", err)
+	}
+	hash, err := worktree.Commit(message, &git.CommitOptions{
+		All: true,
+		Author: &object.Signature{
+			Name:  "getignore",
+			Email: "getignore@example.com",
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	return hash
+}
+
+func testWorktree(t *testing.T, repo *git.Repository) *git.Worktree {
+	t.Helper()
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to open worktree: %v", err)
+	}
+	return worktree
+}
@@ -0,0 +1,93 @@
+package gitignore
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/haroldadmin/getignore/pkg/utils"
+)
+
+// maxHeaderLines limits how much of a file is read for its header
+const maxHeaderLines = 50
+
+// minDescriptionWords is the number of words text before a URL needs to be
+// kept as a description
+const minDescriptionWords = 3
+
+var (
+	urlPattern              = regexp.MustCompile(`https?://[^\s()<>"']+`)
+	parenthesizedURLPattern = regexp.MustCompile(`\(\s*https?://[^\s()]*\s*\)`)
+)
+
+// parseHeader reads the comment lines at the start of a gitignore file. The
+// description is the first paragraph of comments, unless it only labels the
+// rules right below it, as in "# Logs\nlogs\n*.log". The links are the URLs
+// mentioned in any of the comments before the first rule.
+func parseHeader(reader io.Reader) (string, []string, error) {
+	scanner := bufio.NewScanner(reader)
+	paragraph := []string{}
+	paragraphEnded := false
+	var links []string
+	seenLinks := utils.NewSet()
+
+	for lines := 0; lines < maxHeaderLines && scanner.Scan(); lines++ {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") {
+			if line == "" {
+				paragraphEnded = paragraphEnded || len(paragraph) > 0
+				continue
+			}
+			if !paragraphEnded {
+				paragraph = nil
+			}
+			break
+		}
+
+		for _, link := range urlPattern.FindAllString(line, -1) {
+			link = strings.TrimRight(link, ".,;:")
+			if !seenLinks.Contains(link) {
+				seenLinks.Add(link)
+				links = append(links, link)
+			}
+		}
+
+		text := strings.TrimSpace(strings.Trim(line, "#"))
+		if strings.Trim(text, "#-=*_~ ") == "" {
+			// Bare "#" lines and rulers such as "#-----#" separate paragraphs
+			paragraphEnded = paragraphEnded || len(paragraph) > 0
+			continue
+		}
+		if !paragraphEnded {
+			paragraph = append(paragraph, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+
+	return describe(paragraph), links, nil
+}
+
+// describe joins the lines of [paragraph] into a description. URLs are
+// returned as links instead, so the description ends at the first URL which
+// is not in parentheses: the text around it refers to the link, as in
+// "Format documentation: https://...".
+func describe(paragraph []string) string {
+	words := []string{}
+	for _, line := range paragraph {
+		line = parenthesizedURLPattern.ReplaceAllString(line, "")
+		if location := urlPattern.FindStringIndex(line); location != nil {
+			// Text leading up to the first URL describes the file, unless it
+			// only introduces the link, as in "See https://..."
+			lead := strings.Fields(line[:location[0]])
+			if len(words) == 0 && len(lead) >= minDescriptionWords {
+				words = append(words, lead...)
+			}
+			break
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.Trim(strings.Join(words, " "), " :,-")
+}
@@ -0,0 +1,103 @@
+package gitignore_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		contents    string
+		description string
+		links       []string
+	}{
+		{
+			name:        "it should describe files with the first paragraph of comments",
+			contents:    "# gitignore template for Drupal 8 projects\n#\n# earlier versions are not supported\n\n/vendor/\n",
+			description: "gitignore template for Drupal 8 projects",
+		},
+		{
+			name:        "it should join the lines of the paragraph",
+			contents:    "## Ignore Visual Studio temporary files, build results, and\n## files generated by popular Visual Studio add-ons.\n\n*.suo\n",
+			description: "Ignore Visual Studio temporary files, build results, and files generated by popular Visual Studio add-ons.",
+		},
+		{
+			name:        "it should move URLs to the links",
+			contents:    "# For projects using Nanoc (http://nanoc.ws/)\n\n# Default location for output\noutput/\n",
+			description: "For projects using Nanoc",
+			links:       []string{"http://nanoc.ws/"},
+		},
+		{
+			name:        "it should end the description at the first link",
+			contents:    "# For PCBs designed using KiCad: http://www.kicad-pcb.org/\n# Format documentation: http://kicad-pcb.org/help/file-formats/\n\n*.bak\n",
+			description: "For PCBs designed using KiCad",
+			links:       []string{"http://www.kicad-pcb.org/", "http://kicad-pcb.org/help/file-formats/"},
+		},
+		{
+			name:     "it should leave out text introducing a link",
+			contents: "# See https://www.dartlang.org/guides/libraries/private-files\n\n.packages\n",
+			links:    []string{"https://www.dartlang.org/guides/libraries/private-files"},
+		},
+		{
+			name:     "it should collect the links of all comments before the first rule",
+			contents: "# https://www.gnu.org/software/automake\n\nMakefile.in\n\n# https://example.com/after-rules\n",
+			links:    []string{"https://www.gnu.org/software/automake"},
+		},
+		{
+			name:     "it should not describe files with comments labelling their rules",
+			contents: "# Binaries for programs and plugins\n*.exe\n*.dll\n",
+		},
+		{
+			name:        "it should skip rulers",
+			contents:    "#----------------------#\n# Magento Default Files #\n#----------------------#\n/app/etc/local.xml\n",
+			description: "Magento Default Files",
+		},
+		{
+			name:     "it should describe files without comments as empty",
+			contents: "*.class\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, err := gitignore.CreateFromSources(context.Background(), gitignore.Source{
+				Name:      "acme",
+				Templates: &mapSource{files: map[string]string{"/Test.gitignore": test.contents}},
+			})
+			assert.NoError(t, err)
+
+			file, err := service.Get(context.Background(), "Test.gitignore")
+			assert.NoError(t, err)
+			assert.Empty(t, file.Description)
+
+			described, err := service.Describe(context.Background(), file)
+			assert.NoError(t, err)
+			assert.Equal(t, test.description, described.Description)
+			assert.Equal(t, test.links, described.Links)
+		})
+	}
+
+	t.Run("it should store the headers of indexed sources", func(t *testing.T) {
+		templates := &countingSource{mapSource: &mapSource{
+			revision: "1",
+			files:    map[string]string{"/Nanoc.gitignore": "# For projects using Nanoc (http://nanoc.ws/)\n\noutput/\n"},
+		}}
+		source := gitignore.Source{Name: "acme", Templates: templates, IndexPath: filepath.Join(t.TempDir(), "index.json")}
+
+		_, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+		service, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Nanoc.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, templates.lists)
+		assert.Equal(t, "For projects using Nanoc", file.Description)
+		assert.Equal(t, []string{"http://nanoc.ws/"}, file.Links)
+	})
+}
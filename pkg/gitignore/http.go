@@ -0,0 +1,84 @@
+package gitignore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/pkg/remote"
+)
+
+// HTTPSource creates a Source which lists the templates in the index served
+// by [client], and downloads each template only when it is read
+func HTTPSource(ctx context.Context, name string, client *remote.Client) (Source, error) {
+	logger := logs.CreateLogger("gitignore.http")
+
+	paths, err := client.Index(ctx)
+	if err != nil {
+		message := "failed to read template index"
+		logger.Errorf("%s: %v", message, err)
+		return Source{}, fmt.Errorf("%w: %s: %v", ErrInvalidSource, message, err)
+	}
+
+	// The index is laid out as empty files, so that templates are named and
+	// listed like the files of any other source
+	index := memfs.New()
+	for _, path := range paths {
+		file, err := index.Create(path)
+		if err != nil {
+			return Source{}, fmt.Errorf("%w: %v", ErrInvalidSource, err)
+		}
+		file.Close()
+	}
+
+	logger.Infof("found %d templates in index", len(paths))
+	return Source{
+		Name: name,
+		Templates: &httpSource{
+			index: &filesystemSource{filesystem: index},
+			fetch: func(ctx context.Context, filename string) ([]byte, error) {
+				return client.Fetch(ctx, filepath.ToSlash(filename))
+			},
+		},
+	}, nil
+}
+
+// httpSource is a TemplateSource listing the files of a template index.
+// Opening a file downloads it with fetch, using the context it is opened
+// with.
+type httpSource struct {
+	index *filesystemSource
+	fetch func(ctx context.Context, filename string) ([]byte, error)
+}
+
+func (s *httpSource) List(ctx context.Context) ([]string, error) {
+	return s.index.List(ctx)
+}
+
+func (s *httpSource) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	if _, err := s.index.filesystem.Stat(path); err != nil {
+		return nil, err
+	}
+
+	contents, err := s.fetch(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(contents)), nil
+}
+
+func (s *httpSource) Revision() string {
+	return ""
+}
+
+// isDownloaded reports whether the files of [templates] are downloaded when
+// they are opened
+func isDownloaded(templates TemplateSource) bool {
+	_, ok := templates.(*httpSource)
+	return ok
+}
@@ -0,0 +1,92 @@
+package gitignore_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/haroldadmin/getignore/pkg/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPSource(t *testing.T) {
+	files := map[string]string{
+		"/index.json":             `["Go.gitignore", "Global/macOS.gitignore", "README.md"]`,
+		"/Go.gitignore":           "*.exe\n",
+		"/Global/macOS.gitignore": ".DS_Store\n",
+	}
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		contents, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(contents))
+	}))
+	defer server.Close()
+
+	client, err := remote.New(remote.Options{BaseURL: server.URL, CacheDir: t.TempDir()})
+	assert.NoError(t, err)
+	source, err := gitignore.HTTPSource(context.Background(), "web", client)
+	assert.NoError(t, err)
+	service, err := gitignore.CreateFromSources(context.Background(), source)
+	assert.NoError(t, err)
+
+	t.Run("it should list the templates of the index without downloading them", func(t *testing.T) {
+		names := []string{}
+		for _, file := range service.GetAll() {
+			names = append(names, file.Name)
+		}
+		sort.Strings(names)
+		assert.Equal(t, []string{"Go.gitignore", "macOS.gitignore"}, names)
+		assert.Equal(t, 0, requests["/Global/macOS.gitignore"])
+	})
+
+	t.Run("it should download templates when they are written", func(t *testing.T) {
+		file, err := service.Get(context.Background(), "macOS.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join("/", "Global", "macOS.gitignore"), file.Path)
+
+		destFs := memfs.New()
+		assert.NoError(t, service.Write(context.Background(), file, destFs))
+
+		written, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer written.Close()
+		contents, err := ioutil.ReadAll(written)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), ".DS_Store")
+		assert.Equal(t, 1, requests["/Global/macOS.gitignore"])
+	})
+
+	t.Run("it should not download templates to describe them", func(t *testing.T) {
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+
+		described, err := service.Describe(context.Background(), file)
+		assert.NoError(t, err)
+		assert.Equal(t, file, described)
+		assert.Equal(t, 0, requests["/Go.gitignore"])
+	})
+	t.Run("it should download templates with the context they are written with", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		source, err := gitignore.HTTPSource(ctx, "web", client)
+		assert.NoError(t, err)
+		service, err := gitignore.CreateFromSources(ctx, source)
+		assert.NoError(t, err)
+		cancel()
+
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.NoError(t, service.Write(context.Background(), file, memfs.New()))
+		assert.Equal(t, 1, requests["/Go.gitignore"])
+	})
+}
@@ -0,0 +1,123 @@
+package gitignore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/pkg/utils"
+)
+
+// indexVersion changes along with the layout of templateIndex, so that
+// indexes written by other versions of getignore are rebuilt
+const indexVersion = 2
+
+// templateIndex is the list of gitignore files found in a source at one
+// revision, stored at Source.IndexPath
+type templateIndex struct {
+	Version  int           `json:"version"`
+	Revision string        `json:"revision"`
+	Files    []indexedFile `json:"files"`
+}
+
+type indexedFile struct {
+	Name        string   `json:"name"`
+	Path        string   `json:"path"`
+	Category    string   `json:"category,omitempty"`
+	AliasOf     string   `json:"aliasOf,omitempty"`
+	Hash        string   `json:"hash,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Links       []string `json:"links,omitempty"`
+}
+
+// isIndexed reports whether the files of [source] at [revision] are stored
+// in an index
+func isIndexed(source Source, revision string) bool {
+	return source.IndexPath != "" && revision != ""
+}
+
+// readIndex returns the gitignore files of [source] stored in its index, if
+// they were found at [revision]
+func readIndex(source Source, revision string) ([]GitIgnoreFile, bool) {
+	logger := logs.CreateLogger("gitignore.index")
+	if !isIndexed(source, revision) {
+		return nil, false
+	}
+
+	contents, err := ioutil.ReadFile(source.IndexPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("failed to read index of source %q: %v", source.Name, err)
+		}
+		return nil, false
+	}
+
+	index := templateIndex{}
+	if err := json.Unmarshal(contents, &index); err != nil {
+		logger.Warnf("ignoring invalid index of source %q: %v", source.Name, err)
+		return nil, false
+	}
+	if index.Version != indexVersion || index.Revision != revision {
+		logger.Debugf("index of source %q is out of date", source.Name)
+		return nil, false
+	}
+
+	gitIgnores := make([]GitIgnoreFile, len(index.Files))
+	for i, file := range index.Files {
+		gitIgnores[i] = GitIgnoreFile{
+			Name:        file.Name,
+			Path:        filepath.FromSlash(file.Path),
+			Category:    file.Category,
+			AliasOf:     file.AliasOf,
+			Source:      source.Name,
+			Hash:        file.Hash,
+			Description: file.Description,
+			Links:       file.Links,
+		}
+	}
+	return gitIgnores, true
+}
+
+// writeIndex stores [gitIgnores] as the files of [source] at [revision].
+// Failures only cost listing the source again next time, so they are logged
+// and otherwise ignored.
+func writeIndex(source Source, revision string, gitIgnores []GitIgnoreFile) {
+	logger := logs.CreateLogger("gitignore.index")
+	if !isIndexed(source, revision) {
+		return
+	}
+
+	index := templateIndex{
+		Version:  indexVersion,
+		Revision: revision,
+		Files:    make([]indexedFile, len(gitIgnores)),
+	}
+	for i, file := range gitIgnores {
+		index.Files[i] = indexedFile{
+			Name:        file.Name,
+			Path:        filepath.ToSlash(file.Path),
+			Category:    file.Category,
+			AliasOf:     file.AliasOf,
+			Hash:        file.Hash,
+			Description: file.Description,
+			Links:       file.Links,
+		}
+	}
+
+	contents, err := json.Marshal(index)
+	if err != nil {
+		logger.Warnf("failed to encode index of source %q: %v", source.Name, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(source.IndexPath), 0755); err != nil {
+		logger.Warnf("failed to create index directory of source %q: %v", source.Name, err)
+		return
+	}
+	if err := utils.WriteFileAtomic(source.IndexPath, contents); err != nil {
+		logger.Warnf("failed to write index of source %q: %v", source.Name, err)
+		return
+	}
+	logger.Debugf("indexed %d files of source %q", len(gitIgnores), source.Name)
+}
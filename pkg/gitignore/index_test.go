@@ -0,0 +1,125 @@
+package gitignore_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingSource is a mapSource which counts how often it is listed
+type countingSource struct {
+	*mapSource
+	lists int
+}
+
+func (s *countingSource) List(ctx context.Context) ([]string, error) {
+	s.lists++
+	return s.mapSource.List(ctx)
+}
+
+func TestIndex(t *testing.T) {
+	files := map[string]string{
+		"/Go.gitignore":           "go\n",
+		"/Global/macOS.gitignore": "macos\n",
+	}
+
+	t.Run("it should reuse the index while the revision is unchanged", func(t *testing.T) {
+		indexPath := filepath.Join(t.TempDir(), "index.json")
+		templates := &countingSource{mapSource: &mapSource{revision: "1", files: files}}
+		source := gitignore.Source{Name: "acme", Templates: templates, IndexPath: indexPath}
+
+		first, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+		second, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, templates.lists)
+		assert.Equal(t, first.GetAll(), second.GetAll())
+
+		file, err := second.Get(context.Background(), "Global/macOS.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Global", file.Category)
+		assert.Contains(t, writtenContents(t, second, file), "macos")
+	})
+
+	t.Run("it should rebuild the index when the revision changes", func(t *testing.T) {
+		indexPath := filepath.Join(t.TempDir(), "index.json")
+		templates := &countingSource{mapSource: &mapSource{revision: "1", files: files}}
+		source := gitignore.Source{Name: "acme", Templates: templates, IndexPath: indexPath}
+
+		_, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+
+		templates.mapSource = &mapSource{revision: "2", files: map[string]string{"/Rust.gitignore": "rust\n"}}
+		service, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, templates.lists)
+		_, err = service.Get(context.Background(), "Rust.gitignore")
+		assert.NoError(t, err)
+		_, err = service.Get(context.Background(), "Go.gitignore")
+		assert.Error(t, err)
+	})
+
+	t.Run("it should not index sources without revisions", func(t *testing.T) {
+		indexPath := filepath.Join(t.TempDir(), "index.json")
+		templates := &countingSource{mapSource: &mapSource{files: files}}
+		source := gitignore.Source{Name: "acme", Templates: templates, IndexPath: indexPath}
+
+		_, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+		_, err = gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, templates.lists)
+		_, err = os.Stat(indexPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("it should replace invalid indexes", func(t *testing.T) {
+		indexPath := filepath.Join(t.TempDir(), "index.json")
+		assert.NoError(t, ioutil.WriteFile(indexPath, []byte("{invalid"), 0644))
+		templates := &countingSource{mapSource: &mapSource{revision: "1", files: files}}
+		source := gitignore.Source{Name: "acme", Templates: templates, IndexPath: indexPath}
+
+		service, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+		assert.Len(t, service.GetAll(), 2)
+
+		_, err = gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, templates.lists)
+	})
+
+	t.Run("it should store the blob hashes and aliases of repository sources", func(t *testing.T) {
+		repo := linkedRepository(
+			t,
+			map[string]string{"Java.gitignore": "java\n"},
+			map[string]string{"Kotlin.gitignore": "Java.gitignore"},
+		)
+		source := gitignore.RepositorySource("acme", repo)
+		source.IndexPath = filepath.Join(t.TempDir(), "index.json")
+
+		first, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+		second, err := gitignore.CreateFromSources(context.Background(), source)
+		assert.NoError(t, err)
+		assert.Equal(t, first.GetAll(), second.GetAll())
+
+		java, err := second.Get(context.Background(), "Java.gitignore")
+		assert.NoError(t, err)
+		blobHash := plumbing.ComputeHash(plumbing.BlobObject, []byte("java\n"))
+		assert.Equal(t, blobHash.String(), java.Hash)
+
+		kotlin, err := second.Get(context.Background(), "Kotlin.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Java.gitignore", kotlin.AliasOf)
+		assert.Contains(t, writtenContents(t, second, kotlin), "java")
+	})
+}
@@ -0,0 +1,159 @@
+package gitignore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Metadata describes the contents of a template in a git repository, and the
+// last commit which changed them
+type Metadata struct {
+	// Hash is the blob hash of the contents
+	Hash string
+	// Size is the size of the contents in bytes
+	Size int64
+	// Commit is the hash of the last commit which changed the contents
+	Commit string
+	// Date is when the last commit was authored
+	Date time.Time
+	// Author is the author of the last commit, such as
+	// "Jane Doe <jane@example.com>"
+	Author string
+	// Truncated reports that the history of a shallow clone ends before the
+	// last change was found. Commit, Date and Author then describe the
+	// oldest commit available, and the contents were last changed by it or
+	// by one of its missing ancestors.
+	Truncated bool
+}
+
+// MetadataSource is a TemplateSource which knows the history of its files,
+// such as a git repository
+type MetadataSource interface {
+	TemplateSource
+	// Metadata returns the Metadata of the file at [path], or of the file it
+	// points to if it is a symlink. Walking the history stops early when
+	// [ctx] is cancelled.
+	Metadata(ctx context.Context, path string) (Metadata, error)
+}
+
+func (s *repositorySource) Metadata(ctx context.Context, filePath string) (Metadata, error) {
+	if s.commit == nil {
+		return Metadata{}, fmt.Errorf("%w: the repository has no commits", ErrNoHistory)
+	}
+
+	target, err := s.Link(filePath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	name := strings.TrimPrefix(filepath.ToSlash(target), "/")
+	treeFile, err := s.commit.File(name)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("%w: %s: %v", ErrNotFound, target, err)
+	}
+
+	if s.history == nil {
+		s.history = &fileHistory{next: s.commit, changes: map[string]*object.Commit{}}
+	}
+	commit, err := s.history.lastChange(ctx, name)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	return Metadata{
+		Hash:      treeFile.Hash.String(),
+		Size:      treeFile.Size,
+		Commit:    commit.Hash.String(),
+		Date:      commit.Author.When,
+		Author:    fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email),
+		Truncated: commit.Hash == s.history.boundary,
+	}, nil
+}
+
+// fileHistory finds the last commits which changed files by walking the
+// first parents of a commit. The walk is shared by all files, so that each
+// commit is compared with its parent only once.
+type fileHistory struct {
+	// next is the next commit to compare with its parent. It is nil once
+	// the history is exhausted.
+	next *object.Commit
+	// changes are the last commits which changed the files seen so far, by
+	// slash separated path
+	changes map[string]*object.Commit
+	// boundary is the oldest commit of a shallow clone, whose parents are
+	// missing. It is the zero hash until the walk reaches it.
+	boundary plumbing.Hash
+}
+
+// lastChange returns the last commit which changed the file [name]. The walk
+// stops early when [ctx] is cancelled, and resumes from there on the next
+// call.
+func (h *fileHistory) lastChange(ctx context.Context, name string) (*object.Commit, error) {
+	for {
+		if commit, ok := h.changes[name]; ok {
+			return commit, nil
+		}
+		if h.next == nil {
+			return nil, fmt.Errorf("%w: no commit changed %s", ErrNotFound, name)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := h.step(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoHistory, err)
+		}
+	}
+}
+
+// step records the files changed by the next commit, and moves on to its
+// first parent
+func (h *fileHistory) step() error {
+	commit := h.next
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	var parent *object.Commit
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err = commit.Parent(0)
+		if err == nil {
+			parentTree, err = parent.Tree()
+		}
+		// Shallow clones end at commits whose parents are missing. Files
+		// which were not changed since are reported as changed by the
+		// oldest commit available, which is recorded as the boundary.
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			parent, parentTree, err = nil, nil, nil
+			h.boundary = commit.Hash
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		name := change.To.Name
+		if name == "" {
+			// Deleted by this commit
+			continue
+		}
+		if _, ok := h.changes[name]; !ok {
+			h.changes[name] = commit
+		}
+	}
+
+	h.next = parent
+	return nil
+}
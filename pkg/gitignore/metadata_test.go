@@ -0,0 +1,125 @@
+package gitignore_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	repo, first := historyRepository(t)
+	head, err := repo.Head()
+	assert.NoError(t, err)
+	service, err := gitignore.CreateFromSources(context.Background(), gitignore.RepositorySource("acme", repo))
+	assert.NoError(t, err)
+
+	t.Run("it should describe the contents and the last change of a file", func(t *testing.T) {
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+
+		metadata, err := service.Metadata(context.Background(), file)
+		assert.NoError(t, err)
+		assert.Equal(t, plumbing.ComputeHash(plumbing.BlobObject, []byte("go-v2\n")).String(), metadata.Hash)
+		assert.Equal(t, int64(len("go-v2\n")), metadata.Size)
+		assert.Equal(t, head.Hash().String(), metadata.Commit)
+		assert.Equal(t, "getignore <getignore@example.com>", metadata.Author)
+		assert.False(t, metadata.Date.IsZero())
+	})
+
+	t.Run("it should describe files at a revision", func(t *testing.T) {
+		file, err := service.Get(context.Background(), "Old.gitignore@v1")
+		assert.NoError(t, err)
+
+		metadata, err := service.Metadata(context.Background(), file)
+		assert.NoError(t, err)
+		assert.Equal(t, first.String(), metadata.Commit)
+	})
+
+	t.Run("it should describe the target of aliases", func(t *testing.T) {
+		repo := linkedRepository(
+			t,
+			map[string]string{"Java.gitignore": "java\n"},
+			map[string]string{"Kotlin.gitignore": "Java.gitignore"},
+		)
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.RepositorySource("acme", repo))
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Kotlin.gitignore")
+		assert.NoError(t, err)
+		metadata, err := service.Metadata(context.Background(), file)
+		assert.NoError(t, err)
+		assert.Equal(t, plumbing.ComputeHash(plumbing.BlobObject, []byte("java\n")).String(), metadata.Hash)
+		assert.Equal(t, int64(len("java\n")), metadata.Size)
+	})
+
+	t.Run("it should report the history of shallow clones as truncated", func(t *testing.T) {
+		repo, head := shallowRepository(t)
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.RepositorySource("acme", repo))
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		metadata, err := service.Metadata(context.Background(), file)
+		assert.NoError(t, err)
+		assert.True(t, metadata.Truncated)
+		assert.Equal(t, head.String(), metadata.Commit)
+	})
+
+	t.Run("it should not report complete histories as truncated", func(t *testing.T) {
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		metadata, err := service.Metadata(context.Background(), file)
+		assert.NoError(t, err)
+		assert.False(t, metadata.Truncated)
+	})
+
+	t.Run("it should fail for sources without history", func(t *testing.T) {
+		for _, source := range []gitignore.Source{
+			{Name: "plain", Templates: &mapSource{files: map[string]string{"/Go.gitignore": "go\n"}}},
+			gitignore.RepositorySource("worktree", memoryRepository(t, map[string]string{"Go.gitignore": "go\n"})),
+		} {
+			service, err := gitignore.CreateFromSources(context.Background(), source)
+			assert.NoError(t, err)
+
+			file, err := service.Get(context.Background(), "Go.gitignore")
+			assert.NoError(t, err)
+			_, err = service.Metadata(context.Background(), file)
+			assert.True(t, errors.Is(err, gitignore.ErrNoHistory))
+		}
+	})
+}
+
+// shallowRepository clones a remote with two commits with depth 1, and
+// returns the clone and the hash of its only commit
+func shallowRepository(t *testing.T) (*git.Repository, plumbing.Hash) {
+	t.Helper()
+
+	dir := t.TempDir()
+	remote, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to create remote: %v", err)
+	}
+
+	var head plumbing.Hash
+	for _, contents := range []string{"go-v1\n", "go-v2\n"} {
+		writeFiles(t, remote, map[string]string{"Go.gitignore": contents})
+		head = commitAll(t, remote, contents)
+	}
+
+	repo, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:   "file://" + filepath.ToSlash(dir),
+		Depth: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to clone remote: %v", err)
+	}
+	return repo, head
+}
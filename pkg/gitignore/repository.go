@@ -0,0 +1,167 @@
+package gitignore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	pkggit "github.com/haroldadmin/getignore/pkg/git"
+	"github.com/haroldadmin/getignore/pkg/utils"
+)
+
+// Create creates a GitIgnoreService backed by a single unnamed repository
+func Create(ctx context.Context, repository *git.Repository) (GitIgnoreService, error) {
+	return CreateFromSources(ctx, RepositorySource("", repository))
+}
+
+// RepositorySource creates a Source which reads gitignore files from the
+// commit checked out in [repository]. The commit is captured when the Source
+// is created, so that another process updating the repository does not
+// change the files halfway through. Repositories without commits are read
+// from their worktree.
+func RepositorySource(name string, repository *git.Repository) Source {
+	source := &repositorySource{repository: repository}
+	if head, err := repository.Head(); err == nil {
+		if commit, err := repository.CommitObject(head.Hash()); err == nil {
+			source.commit = commit
+		}
+	}
+
+	return Source{Name: name, Templates: source}
+}
+
+// repositorySource is a RevisionSource backed by a go-git repository
+type repositorySource struct {
+	repository *git.Repository
+	// commit is the commit files are read from. Files are read from the
+	// worktree when nil.
+	commit *object.Commit
+	// hashes are the blob hashes of the files found by List, and symlinks
+	// their paths which are symlinks, so that the tree is not searched again
+	// for every file
+	hashes   map[string]string
+	symlinks utils.StringSet
+	// history is walked by Metadata when it is first needed
+	history *fileHistory
+}
+
+func (s *repositorySource) List(ctx context.Context) ([]string, error) {
+	if s.commit == nil {
+		worktree, err := s.worktree()
+		if err != nil {
+			return nil, err
+		}
+		return worktree.List(ctx)
+	}
+
+	tree, err := s.commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	hashes := map[string]string{}
+	symlinks := utils.NewSet()
+	err = tree.Files().ForEach(func(file *object.File) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		filePath := filepath.FromSlash("/" + file.Name)
+		paths = append(paths, filePath)
+		hashes[filePath] = file.Hash.String()
+		if file.Mode == filemode.Symlink {
+			symlinks.Add(filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.hashes = hashes
+	s.symlinks = symlinks
+	return paths, nil
+}
+
+func (s *repositorySource) Open(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	if s.commit == nil {
+		worktree, err := s.worktree()
+		if err != nil {
+			return nil, err
+		}
+		return worktree.Open(ctx, filePath)
+	}
+
+	target, err := s.Link(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return openInCommit(s.commit, target)
+}
+
+// Hash returns the blob hash of the file at [filePath] in the commit. Files
+// of the worktree have no hash.
+func (s *repositorySource) Hash(filePath string) (string, error) {
+	if s.commit == nil {
+		return "", nil
+	}
+	if hash, ok := s.hashes[filePath]; ok {
+		return hash, nil
+	}
+
+	treeFile, err := s.commit.File(strings.TrimPrefix(filepath.ToSlash(filePath), "/"))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrNotFound, filePath, err)
+	}
+	return treeFile.Hash.String(), nil
+}
+
+func (s *repositorySource) Revision() string {
+	if s.commit == nil {
+		return ""
+	}
+	return s.commit.Hash.String()
+}
+
+func (s *repositorySource) At(revision string) (TemplateSource, error) {
+	hash, err := pkggit.ResolveRef(s.repository, revision)
+	if err != nil {
+		if shallows, _ := s.repository.Storer.Shallow(); len(shallows) > 0 {
+			return nil, fmt.Errorf(
+				"%w: %q is not in the shallow clone of the repository",
+				ErrUnknownRevision,
+				revision,
+			)
+		}
+		return nil, fmt.Errorf("%w: %q is not in the repository", ErrUnknownRevision, revision)
+	}
+
+	commit, err := s.repository.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownRevision, err)
+	}
+	return &repositorySource{repository: s.repository, commit: commit}, nil
+}
+
+func (s *repositorySource) worktree() (*filesystemSource, error) {
+	worktree, err := s.repository.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorktree, err)
+	}
+	return &filesystemSource{filesystem: worktree.Filesystem}, nil
+}
+
+// openInCommit opens the blob at [filePath] in the tree of [commit]
+func openInCommit(commit *object.Commit, filePath string) (io.ReadCloser, error) {
+	treeFile, err := commit.File(strings.TrimPrefix(filepath.ToSlash(filePath), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNotFound, filePath, err)
+	}
+	return treeFile.Reader()
+}
@@ -0,0 +1,119 @@
+package gitignore
+
+import (
+	"context"
+	"io"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/pkg/fs"
+	"github.com/haroldadmin/getignore/pkg/utils"
+)
+
+// TemplateSource provides the files of a template collection, such as a git
+// repository or a plain directory. Paths are slash or OS separated, rooted
+// at the collection, like "/Global/macOS.gitignore".
+type TemplateSource interface {
+	// List returns the paths of all files in the collection. Files without
+	// a ".gitignore" extension are ignored by GitIgnoreService. Listing
+	// stops early when [ctx] is cancelled.
+	List(ctx context.Context) ([]string, error)
+	// Open opens the contents of the file at [path]. Sources which download
+	// their files stop early when [ctx] is cancelled.
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	// Revision describes the version of the files, such as a commit hash.
+	// It is empty for collections without versions.
+	Revision() string
+}
+
+// RevisionSource is a TemplateSource which can also read its files as they
+// were at another revision, such as a branch, tag or commit of a git
+// repository
+type RevisionSource interface {
+	TemplateSource
+	// At returns the files as they were at [revision]. The Revision of the
+	// returned source identifies it exactly, such as a full commit hash.
+	At(revision string) (TemplateSource, error)
+}
+
+// HashSource is a TemplateSource which identifies the contents of its files,
+// such as by their git blob hashes
+type HashSource interface {
+	TemplateSource
+	// Hash returns an identifier of the contents of the file at [path],
+	// which changes whenever they do
+	Hash(path string) (string, error)
+}
+
+// Source is a named collection of gitignore files
+type Source struct {
+	Name      string
+	Templates TemplateSource
+	// IndexPath is a file storing the gitignore files found in Templates,
+	// so that they are not listed again until its revision changes. Sources
+	// without revisions are never indexed, and neither are sources without
+	// an IndexPath.
+	IndexPath string
+}
+
+// DirSource creates a Source which reads gitignore files from the plain
+// directory [dir]
+func DirSource(name string, dir string) Source {
+	return FilesystemSource(name, osfs.New(dir))
+}
+
+// FilesystemSource creates a Source which reads gitignore files from
+// [filesystem], such as a shared directory or an extracted archive
+func FilesystemSource(name string, filesystem billy.Filesystem) Source {
+	return Source{
+		Name:      name,
+		Templates: &filesystemSource{filesystem: filesystem},
+	}
+}
+
+// filesystemSource is a TemplateSource without revisions
+type filesystemSource struct {
+	filesystem billy.Filesystem
+	// symlinks are the paths of the symlinks found by List, so that other
+	// files are not checked again
+	symlinks utils.StringSet
+}
+
+func (s *filesystemSource) List(ctx context.Context) ([]string, error) {
+	logger := logs.CreateLogger("gitignore.list")
+
+	files, err := fs.ReadDirConcurrently(ctx, s.filesystem, fs.WalkOptions{})
+	if err != nil {
+		// Partial results are only useful if the walk was not cancelled
+		if len(files) == 0 || ctx.Err() != nil {
+			return nil, err
+		}
+		logger.Warnf("some templates could not be listed: %v", err)
+	}
+
+	paths := make([]string, 0, len(files))
+	symlinks := utils.NewSet()
+	for _, file := range files {
+		paths = append(paths, file.Path)
+		if file.IsSymlink {
+			symlinks.Add(file.Path)
+		}
+	}
+	s.symlinks = symlinks
+	return paths, nil
+}
+
+// Open opens the file at [path], after resolving symlinks so that links
+// pointing outside of the filesystem are never followed
+func (s *filesystemSource) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	target, err := s.Link(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.filesystem.Open(target)
+}
+
+func (s *filesystemSource) Revision() string {
+	return ""
+}
@@ -0,0 +1,145 @@
+package gitignore_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapSource is an in-memory TemplateSource. Its revisions are other
+// mapSources by revision name.
+type mapSource struct {
+	revision  string
+	files     map[string]string
+	revisions map[string]*mapSource
+}
+
+func (s *mapSource) List(ctx context.Context) ([]string, error) {
+	paths := []string{}
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (s *mapSource) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	contents, ok := s.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(strings.NewReader(contents)), nil
+}
+
+func (s *mapSource) Revision() string {
+	return s.revision
+}
+
+// revisionMapSource is a mapSource which implements RevisionSource
+type revisionMapSource struct {
+	*mapSource
+}
+
+func (s revisionMapSource) At(revision string) (gitignore.TemplateSource, error) {
+	source, ok := s.revisions[revision]
+	if !ok {
+		return nil, gitignore.ErrUnknownRevision
+	}
+	return source, nil
+}
+
+func TestTemplateSource(t *testing.T) {
+	current := &mapSource{
+		revision: "2",
+		files: map[string]string{
+			"/Go.gitignore":           "go-v2\n",
+			"/Global/macOS.gitignore": ".DS_Store\n",
+			"/README.md":              "# Templates\n",
+		},
+	}
+	previous := &mapSource{revision: "1", files: map[string]string{"/Go.gitignore": "go-v1\n"}}
+	current.revisions = map[string]*mapSource{"v1": previous, "1": previous}
+
+	t.Run("it should list the gitignore files of a custom source", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.Source{Name: "custom", Templates: current})
+		assert.NoError(t, err)
+
+		names := []string{}
+		for _, file := range service.GetAll() {
+			names = append(names, file.Name)
+		}
+		sort.Strings(names)
+		assert.Equal(t, []string{"Go.gitignore", "macOS.gitignore"}, names)
+	})
+
+	t.Run("it should write the contents of a custom source", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.Source{Name: "custom", Templates: current})
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		destFs := memfs.New()
+		assert.NoError(t, service.Write(context.Background(), file, destFs))
+
+		written, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer written.Close()
+		contents, err := ioutil.ReadAll(written)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), "go-v2")
+	})
+
+	t.Run("it should read revisions of sources which support them", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.Source{
+			Name:      "custom",
+			Templates: revisionMapSource{current},
+		})
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Go.gitignore@v1")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", file.Revision)
+
+		destFs := memfs.New()
+		assert.NoError(t, service.Write(context.Background(), file, destFs))
+		written, err := destFs.Open(".gitignore")
+		assert.NoError(t, err)
+		defer written.Close()
+		contents, err := ioutil.ReadAll(written)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), "go-v1")
+	})
+
+	t.Run("it should reject revisions of sources which do not support them", func(t *testing.T) {
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.Source{Name: "custom", Templates: current})
+		assert.NoError(t, err)
+
+		_, err = service.Get(context.Background(), "custom:Go.gitignore@v1")
+		assert.True(t, errors.Is(err, gitignore.ErrNoRevisions))
+	})
+
+	t.Run("it should reject sources without templates", func(t *testing.T) {
+		_, err := gitignore.CreateFromSources(context.Background(), gitignore.Source{Name: "empty"})
+		assert.True(t, errors.Is(err, gitignore.ErrInvalidSource))
+	})
+
+	t.Run("it should stop listing a directory when the context is cancelled", func(t *testing.T) {
+		filesystem := memfs.New()
+		assert.NoError(t, util.WriteFile(filesystem, "Go.gitignore", []byte("vendor/\n"), 0644))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := gitignore.CreateFromSources(ctx, gitignore.FilesystemSource("dir", filesystem))
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
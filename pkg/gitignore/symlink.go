@@ -0,0 +1,139 @@
+package gitignore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+var ErrEscapingLink = errors.New("link-escapes-root")
+
+// LinkSource is a TemplateSource which can contain symlinks. Templates which
+// are symlinks to other templates are listed as aliases of their targets.
+type LinkSource interface {
+	TemplateSource
+	// Link returns the path of the file the symlink at [path] points to,
+	// following chains of links, or [path] itself if it is not a symlink.
+	// Links pointing outside of the collection fail with ErrEscapingLink.
+	Link(path string) (string, error)
+}
+
+// maxSymlinkHops limits how many symlinks are followed when resolving a
+// file, so that link cycles fail instead of looping forever
+const maxSymlinkHops = 8
+
+// readlinkFunc returns the target of [name] if it is a symlink. Names and
+// targets are slash separated, and names are relative to the root. Names may
+// be directories, which are not links.
+type readlinkFunc func(name string) (target string, isLink bool, err error)
+
+// resolveLinks follows the symlinks in [filePath] with [readlink], and returns
+// the path of the file they point to, rooted like [filePath]. Every component
+// of the path is resolved, so that files are not read through directory links
+// pointing outside of the root either.
+func resolveLinks(filePath string, readlink readlinkFunc) (string, error) {
+	name := strings.TrimPrefix(filepath.ToSlash(filePath), "/")
+	parts := strings.Split(name, "/")
+	hops := 0
+	for index := 0; index < len(parts); index++ {
+		prefix := path.Join(parts[:index+1]...)
+		target, isLink, err := readlink(prefix)
+		if err != nil {
+			return "", err
+		}
+		if !isLink {
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return "", fmt.Errorf("%w: too many levels of symbolic links in %s", ErrInvalidFile, filePath)
+		}
+		absolute := path.IsAbs(target) || filepath.IsAbs(filepath.FromSlash(target))
+		resolved := path.Join(path.Dir(prefix), target)
+		if absolute || escapesRoot(resolved) {
+			return "", fmt.Errorf("%w: %s points to %s", ErrEscapingLink, filePath, target)
+		}
+
+		// The resolved path may contain links again, so it is resolved from
+		// the start
+		name = path.Join(append([]string{resolved}, parts[index+1:]...)...)
+		parts = strings.Split(name, "/")
+		index = -1
+	}
+
+	if hops == 0 {
+		return filePath, nil
+	}
+	return filepath.FromSlash("/" + name), nil
+}
+
+// escapesRoot reports whether the relative path [name] leaves the directory
+// it is relative to
+func escapesRoot(name string) bool {
+	cleaned := path.Clean(name)
+	return cleaned == ".." || strings.HasPrefix(cleaned, "../")
+}
+
+func (s *filesystemSource) Link(filePath string) (string, error) {
+	symlinks, ok := s.filesystem.(billy.Symlink)
+	if !ok {
+		return filePath, nil
+	}
+	if s.symlinks != nil && !s.symlinks.Contains(filePath) {
+		return filePath, nil
+	}
+
+	return resolveLinks(filePath, func(name string) (string, bool, error) {
+		info, err := symlinks.Lstat(name)
+		if err != nil {
+			return "", false, err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return "", false, nil
+		}
+
+		target, err := symlinks.Readlink(name)
+		return filepath.ToSlash(target), true, err
+	})
+}
+
+func (s *repositorySource) Link(filePath string) (string, error) {
+	if s.commit == nil {
+		worktree, err := s.worktree()
+		if err != nil {
+			return "", err
+		}
+		return worktree.Link(filePath)
+	}
+	if s.symlinks != nil && !s.symlinks.Contains(filePath) {
+		return filePath, nil
+	}
+
+	tree, err := s.commit.Tree()
+	if err != nil {
+		return "", err
+	}
+	return resolveLinks(filePath, func(name string) (string, bool, error) {
+		entry, err := tree.FindEntry(name)
+		if err != nil {
+			return "", false, fmt.Errorf("%w: %s: %v", ErrNotFound, name, err)
+		}
+		if entry.Mode != filemode.Symlink {
+			return "", false, nil
+		}
+
+		treeFile, err := tree.TreeEntryFile(entry)
+		if err != nil {
+			return "", false, err
+		}
+		target, err := treeFile.Contents()
+		return target, true, err
+	})
+}
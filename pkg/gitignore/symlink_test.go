@@ -0,0 +1,167 @@
+package gitignore_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/haroldadmin/getignore/pkg/gitignore"
+	"github.com/stretchr/testify/assert"
+)
+
+// linkedRepository commits [files] and the symlinks in [links], by link
+// name, to an in-memory repository
+func linkedRepository(t *testing.T, files map[string]string, links map[string]string) *git.Repository {
+	t.Helper()
+
+	repo := memoryRepository(t, files)
+	worktree := testWorktree(t, repo)
+	for name, target := range links {
+		if err := worktree.Filesystem.Symlink(target, name); err != nil {
+			t.Fatalf("failed to link %s: %v", name, err)
+		}
+	}
+	commitAll(t, repo, "Links")
+
+	return repo
+}
+
+func writtenContents(t *testing.T, service gitignore.GitIgnoreService, file gitignore.GitIgnoreFile) string {
+	t.Helper()
+
+	destFs := memfs.New()
+	assert.NoError(t, service.Write(context.Background(), file, destFs))
+	written, err := destFs.Open(".gitignore")
+	assert.NoError(t, err)
+	defer written.Close()
+	contents, err := ioutil.ReadAll(written)
+	assert.NoError(t, err)
+	return string(contents)
+}
+
+func TestSymlinks(t *testing.T) {
+	t.Run("it should read aliases from the commit of a repository", func(t *testing.T) {
+		repo := linkedRepository(t,
+			map[string]string{"Java.gitignore": "*.class\n", "Global/JetBrains.gitignore": ".idea/\n"},
+			map[string]string{
+				"Kotlin.gitignore":             "Java.gitignore",
+				"community/IntelliJ.gitignore": "../Global/JetBrains.gitignore",
+				"Escape.gitignore":             "../outside.gitignore",
+				"Shared":                       "Global",
+				"Rider.gitignore":              "Shared/JetBrains.gitignore",
+			},
+		)
+		service, err := gitignore.Create(context.Background(), repo)
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Kotlin.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Java.gitignore", file.AliasOf)
+		assert.Contains(t, writtenContents(t, service, file), "*.class")
+
+		file, err = service.Get(context.Background(), "IntelliJ.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Global/JetBrains.gitignore", file.AliasOf)
+		assert.Contains(t, writtenContents(t, service, file), ".idea/")
+
+		file, err = service.Get(context.Background(), "Rider.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Global/JetBrains.gitignore", file.AliasOf)
+
+		file, err = service.Get(context.Background(), "Java.gitignore")
+		assert.NoError(t, err)
+		assert.Empty(t, file.AliasOf)
+
+		_, err = service.Get(context.Background(), "Escape.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrNotFound))
+
+		links, ok := gitignore.RepositorySource("github", repo).Templates.(gitignore.LinkSource)
+		assert.True(t, ok)
+		_, err = links.Link("/Escape.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrEscapingLink))
+		_, err = links.Open(context.Background(), "/Escape.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrEscapingLink))
+	})
+
+	t.Run("it should read aliases from a plain directory", func(t *testing.T) {
+		root := t.TempDir()
+		dir := filepath.Join(root, "templates")
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "Global"), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Java.gitignore"), []byte("*.class\n"), 0644))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "secret.gitignore"), []byte("secret\n"), 0644))
+
+		links := map[string]string{
+			"Kotlin.gitignore":        "Java.gitignore",
+			"Global/Scala.gitignore":  filepath.Join("..", "Java.gitignore"),
+			"Escape.gitignore":        filepath.Join("..", "secret.gitignore"),
+			"Absolute.gitignore":      filepath.Join(root, "secret.gitignore"),
+			"Cycle.gitignore":         "Loop.gitignore",
+			"Loop.gitignore":          "Cycle.gitignore",
+			"Dangling.gitignore":      "Missing.gitignore",
+			"Global/Nested.gitignore": filepath.Join("..", "Kotlin.gitignore"),
+		}
+		for name, target := range links {
+			if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+				t.Skipf("symlinks are not supported: %v", err)
+			}
+		}
+
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.DirSource("local", dir))
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Scala.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Java.gitignore", file.AliasOf)
+		assert.Contains(t, writtenContents(t, service, file), "*.class")
+
+		file, err = service.Get(context.Background(), "Nested.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Java.gitignore", file.AliasOf)
+
+		for _, name := range []string{"Escape.gitignore", "Absolute.gitignore", "Cycle.gitignore", "Dangling.gitignore"} {
+			_, err := service.Get(context.Background(), name)
+			assert.True(t, errors.Is(err, gitignore.ErrNotFound), name)
+		}
+	})
+	t.Run("it should not read files through directory links outside of a plain directory", func(t *testing.T) {
+		root := t.TempDir()
+		dir := filepath.Join(root, "templates")
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "Global"), 0755))
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "outside"), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Global", "Java.gitignore"), []byte("*.class\n"), 0644))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "outside", "secret.gitignore"), []byte("secret\n"), 0644))
+
+		links := map[string]string{
+			"Outside":          filepath.Join("..", "outside"),
+			"Shared":           "Global",
+			"Escape.gitignore": filepath.Join("Outside", "secret.gitignore"),
+			"Kotlin.gitignore": filepath.Join("Shared", "Java.gitignore"),
+		}
+		for name, target := range links {
+			if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+				t.Skipf("symlinks are not supported: %v", err)
+			}
+		}
+
+		service, err := gitignore.CreateFromSources(context.Background(), gitignore.DirSource("local", dir))
+		assert.NoError(t, err)
+
+		file, err := service.Get(context.Background(), "Kotlin.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "Global/Java.gitignore", file.AliasOf)
+		assert.Contains(t, writtenContents(t, service, file), "*.class")
+
+		_, err = service.Get(context.Background(), "Escape.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrNotFound))
+
+		source, ok := gitignore.DirSource("local", dir).Templates.(gitignore.LinkSource)
+		assert.True(t, ok)
+		_, err = source.Open(context.Background(), "/Escape.gitignore")
+		assert.True(t, errors.Is(err, gitignore.ErrEscapingLink))
+	})
+}
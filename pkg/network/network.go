@@ -0,0 +1,137 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/haroldadmin/getignore/internal/logs"
+)
+
+var (
+	ErrInvalidCABundle = errors.New("invalid-ca-bundle")
+	ErrProxy           = errors.New("proxy-error")
+	ErrCertificate     = errors.New("certificate-error")
+)
+
+// CABundleEnv is the environment variable read by OptionsFromEnv
+const CABundleEnv = "GETIGNORE_CA_BUNDLE"
+
+// Options contains config parameters for the HTTP client shared by all
+// network sources
+type Options struct {
+	// CABundle is a PEM file of certificates trusted in addition to the
+	// system roots, such as the root of a TLS intercepting proxy
+	CABundle string
+}
+
+// OptionsFromEnv returns Options with the CA bundle set in the environment
+func OptionsFromEnv() Options {
+	return Options{CABundle: os.Getenv(CABundleEnv)}
+}
+
+// NewHTTPClient creates an HTTP client which sends requests through the
+// proxy set in HTTPS_PROXY, HTTP_PROXY and NO_PROXY, and trusts the system
+// roots along with the certificates in options.CABundle. Requests time out
+// after [timeout], or never when zero.
+func NewHTTPClient(options Options, timeout time.Duration) (*http.Client, error) {
+	logger := logs.CreateLogger("network.client")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if options.CABundle != "" {
+		roots, err := loadRoots(options.CABundle)
+		if err != nil {
+			message := fmt.Sprintf("failed to load CA bundle %s", options.CABundle)
+			logger.Errorf("%s: %v", message, err)
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidCABundle, message, err)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+		logger.Infof("trusting certificates in %s", options.CABundle)
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// loadRoots returns the system roots with the certificates in [bundlePath]
+// added to them
+func loadRoots(bundlePath string) (*x509.CertPool, error) {
+	contents, err := ioutil.ReadFile(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil || roots == nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(contents) {
+		return nil, errors.New("no PEM certificates found")
+	}
+
+	return roots, nil
+}
+
+// DescribeError adds a hint to proxy and certificate errors in [err], which
+// otherwise surface as opaque transport errors. Other errors are returned
+// unchanged.
+func DescribeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var opErr *net.OpError
+	isProxyErr := errors.As(err, &opErr) && opErr.Op == "proxyconnect"
+	if isProxyErr || strings.Contains(err.Error(), "proxyconnect ") {
+		proxy := "the proxy"
+		if isProxyErr && opErr.Addr != nil {
+			proxy = opErr.Addr.String()
+		}
+		return fmt.Errorf(
+			"%w: failed to connect through %s (%v); check HTTPS_PROXY, HTTP_PROXY and NO_PROXY",
+			ErrProxy,
+			proxy,
+			err,
+		)
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return fmt.Errorf(
+			"%w: the server certificate is signed by an unknown authority (%v); pass the CA certificate with --ca-bundle or %s",
+			ErrCertificate,
+			err,
+			CABundleEnv,
+		)
+	case errors.As(err, &hostname):
+		return fmt.Errorf(
+			"%w: the server certificate is not valid for %s (%v)",
+			ErrCertificate,
+			hostname.Host,
+			err,
+		)
+	case errors.As(err, &invalid):
+		return fmt.Errorf("%w: the server certificate is invalid (%v)", ErrCertificate, err)
+	case strings.Contains(err.Error(), "x509: "):
+		// Errors flattened into strings by other libraries
+		return fmt.Errorf(
+			"%w: %v; pass the CA certificate with --ca-bundle or %s",
+			ErrCertificate,
+			err,
+			CABundleEnv,
+		)
+	}
+
+	return err
+}
@@ -0,0 +1,92 @@
+package network_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/haroldadmin/getignore/pkg/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCABundle(t *testing.T, certificate *x509.Certificate) string {
+	t.Helper()
+
+	bundlePath := filepath.Join(t.TempDir(), "ca.pem")
+	contents := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	assert.NoError(t, ioutil.WriteFile(bundlePath, contents, 0644))
+	return bundlePath
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	t.Run("it should trust certificates in the CA bundle", func(t *testing.T) {
+		client, err := network.NewHTTPClient(network.Options{
+			CABundle: writeCABundle(t, server.Certificate()),
+		}, time.Second)
+		assert.NoError(t, err)
+
+		response, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("it should describe untrusted certificates", func(t *testing.T) {
+		client, err := network.NewHTTPClient(network.Options{}, time.Second)
+		assert.NoError(t, err)
+
+		_, err = client.Get(server.URL)
+		assert.Error(t, err)
+		err = network.DescribeError(err)
+		assert.True(t, errors.Is(err, network.ErrCertificate))
+		assert.Contains(t, err.Error(), "--ca-bundle")
+	})
+
+	t.Run("it should reject invalid CA bundles", func(t *testing.T) {
+		_, err := network.NewHTTPClient(network.Options{
+			CABundle: filepath.Join(t.TempDir(), "missing.pem"),
+		}, time.Second)
+		assert.True(t, errors.Is(err, network.ErrInvalidCABundle))
+
+		bundlePath := filepath.Join(t.TempDir(), "ca.pem")
+		assert.NoError(t, ioutil.WriteFile(bundlePath, []byte("not a certificate"), 0644))
+		_, err = network.NewHTTPClient(network.Options{CABundle: bundlePath}, time.Second)
+		assert.True(t, errors.Is(err, network.ErrInvalidCABundle))
+	})
+}
+
+func TestDescribeError(t *testing.T) {
+	t.Run("it should name the proxy which could not be reached", func(t *testing.T) {
+		err := network.DescribeError(&url.Error{
+			Op:  "Get",
+			URL: "https://example.com/index.json",
+			Err: &net.OpError{
+				Op:   "proxyconnect",
+				Net:  "tcp",
+				Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 3128},
+				Err:  errors.New("connection refused"),
+			},
+		})
+		assert.True(t, errors.Is(err, network.ErrProxy))
+		assert.Contains(t, err.Error(), "127.0.0.1:3128")
+	})
+
+	t.Run("it should return other errors unchanged", func(t *testing.T) {
+		original := errors.New("connection reset")
+		assert.Equal(t, original, network.DescribeError(original))
+		assert.Nil(t, network.DescribeError(nil))
+	})
+}
@@ -0,0 +1,68 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultAPIBaseURL is the base URL of gitignore.io, now hosted by Toptal
+const DefaultAPIBaseURL = "https://www.toptal.com/developers/gitignore"
+
+// APIClient lists and downloads templates from a server speaking the
+// gitignore.io protocol: "api/list" lists the template names separated by
+// commas and newlines, and "api/<names>" returns the templates with the
+// given comma separated names
+type APIClient struct {
+	client *Client
+}
+
+// NewAPI creates an APIClient with [options]. The base URL defaults to
+// DefaultAPIBaseURL, and IndexFile is ignored.
+func NewAPI(options Options) (*APIClient, error) {
+	if options.BaseURL == "" {
+		options.BaseURL = DefaultAPIBaseURL
+	}
+
+	client, err := New(options)
+	if err != nil {
+		return nil, err
+	}
+	return &APIClient{client: client}, nil
+}
+
+// List returns the names of all templates, such as "go" or "visualstudiocode"
+func (c *APIClient) List(ctx context.Context) ([]string, error) {
+	contents, err := c.client.get(ctx, "api/list")
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.FieldsFunc(string(contents), func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		name := strings.TrimSpace(field)
+		if name == "" {
+			continue
+		}
+		if strings.ContainsAny(name, "/?#") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidIndex, name)
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// Fetch returns the combined template with the given [names]
+func (c *APIClient) Fetch(ctx context.Context, names ...string) ([]byte, error) {
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, ",/?#") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, name)
+		}
+	}
+	return c.client.get(ctx, "api/"+strings.Join(names, ","))
+}
@@ -0,0 +1,62 @@
+package remote_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/haroldadmin/getignore/pkg/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAPIFiles() map[string]string {
+	return map[string]string{
+		"/developers/gitignore/api/list":    "go,node\r\nvisualstudiocode,\n",
+		"/developers/gitignore/api/go":      "# go\n*.exe\n",
+		"/developers/gitignore/api/go,node": "# go,node\n*.exe\nnode_modules/\n",
+	}
+}
+
+func TestAPIClient(t *testing.T) {
+	t.Run("it should list template names separated by commas and newlines", func(t *testing.T) {
+		server := newTestServer(t, testAPIFiles())
+		client, err := remote.NewAPI(remote.Options{BaseURL: server.URL + "/developers/gitignore"})
+		assert.NoError(t, err)
+
+		names, err := client.List(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"go", "node", "visualstudiocode"}, names)
+	})
+
+	t.Run("it should download combined templates", func(t *testing.T) {
+		server := newTestServer(t, testAPIFiles())
+		client, err := remote.NewAPI(remote.Options{BaseURL: server.URL + "/developers/gitignore/", CacheDir: t.TempDir()})
+		assert.NoError(t, err)
+
+		contents, err := client.Fetch(context.Background(), "go", "node")
+		assert.NoError(t, err)
+		assert.Equal(t, "# go,node\n*.exe\nnode_modules/\n", string(contents))
+
+		_, err = client.Fetch(context.Background(), "go")
+		assert.NoError(t, err)
+		_, err = client.Fetch(context.Background(), "go")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, server.downloadCount("/developers/gitignore/api/go"))
+	})
+
+	t.Run("it should reject invalid template names", func(t *testing.T) {
+		server := newTestServer(t, testAPIFiles())
+		client, err := remote.NewAPI(remote.Options{BaseURL: server.URL})
+		assert.NoError(t, err)
+
+		for _, name := range []string{"", "go,node", "../list"} {
+			_, err := client.Fetch(context.Background(), name)
+			assert.True(t, errors.Is(err, remote.ErrInvalidPath), name)
+		}
+	})
+
+	t.Run("it should default to the gitignore.io base URL", func(t *testing.T) {
+		_, err := remote.NewAPI(remote.Options{})
+		assert.NoError(t, err)
+	})
+}
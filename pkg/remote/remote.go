@@ -0,0 +1,267 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haroldadmin/getignore/internal/logs"
+	"github.com/haroldadmin/getignore/pkg/network"
+	"github.com/haroldadmin/getignore/pkg/utils"
+)
+
+var (
+	ErrInvalidBaseURL = errors.New("invalid-base-url")
+	ErrInvalidIndex   = errors.New("invalid-index")
+	ErrInvalidPath    = errors.New("invalid-path")
+	ErrRequestFailed  = errors.New("request-failed")
+	ErrNotCached      = errors.New("not-cached")
+)
+
+// DefaultIndexFile is the name of the index, relative to the base URL
+const DefaultIndexFile = "index.json"
+
+// DefaultTimeout limits each request when no HTTPClient is set
+const DefaultTimeout = 30 * time.Second
+
+// Options contains config parameters for a Client
+type Options struct {
+	// BaseURL is the URL the index and the templates are relative to, such
+	// as "https://example.com/gitignore"
+	BaseURL string
+	// IndexFile is the path of the index relative to BaseURL. Defaults to
+	// DefaultIndexFile when empty.
+	IndexFile string
+	// CacheDir stores downloaded files, so that they are only downloaded
+	// again when they change
+	CacheDir string
+	// Offline serves cached files without sending any requests. It fails
+	// with ErrNotCached for files which have not been downloaded yet.
+	Offline bool
+	// HTTPClient sends the requests, such as a client created with
+	// network.NewHTTPClient. A client with DefaultTimeout is used when nil.
+	HTTPClient *http.Client
+}
+
+// Client lists and downloads templates hosted on a plain HTTP server. The
+// server serves an index, a JSON array of template paths such as
+// ["Go.gitignore", "Global/macOS.gitignore"], and the raw templates at
+// those paths.
+type Client struct {
+	baseURL    *url.URL
+	indexFile  string
+	cacheDir   string
+	offline    bool
+	httpClient *http.Client
+}
+
+// New creates a Client with [options]
+func New(options Options) (*Client, error) {
+	baseURL, err := url.Parse(options.BaseURL)
+	if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return nil, fmt.Errorf("%w: %q, expected an http(s) URL", ErrInvalidBaseURL, options.BaseURL)
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	indexFile := options.IndexFile
+	if indexFile == "" {
+		indexFile = DefaultIndexFile
+	}
+
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		indexFile:  indexFile,
+		cacheDir:   options.CacheDir,
+		offline:    options.Offline,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Index returns the paths of the templates listed in the index
+func (c *Client) Index(ctx context.Context) ([]string, error) {
+	contents, err := c.get(ctx, c.indexFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if err := json.Unmarshal(contents, &paths); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+	}
+
+	valid := make([]string, 0, len(paths))
+	for _, templatePath := range paths {
+		cleaned, err := cleanPath(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+		}
+		valid = append(valid, cleaned)
+	}
+
+	return valid, nil
+}
+
+// Fetch returns the contents of the template at [templatePath], relative to
+// the base URL
+func (c *Client) Fetch(ctx context.Context, templatePath string) ([]byte, error) {
+	cleaned, err := cleanPath(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	return c.get(ctx, cleaned)
+}
+
+// cleanPath rejects paths which leave the base URL
+func cleanPath(templatePath string) (string, error) {
+	cleaned := path.Clean("/" + templatePath)[1:]
+	if cleaned == "" || strings.Contains(templatePath, "..") || strings.Contains(templatePath, "://") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, templatePath)
+	}
+	return cleaned, nil
+}
+
+// cacheEntry describes a downloaded file
+type cacheEntry struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// get downloads [relativePath], sending the validators of the cached copy
+// so that unchanged files are not downloaded again. The cached copy is used
+// when the server can not be reached.
+func (c *Client) get(ctx context.Context, relativePath string) ([]byte, error) {
+	logger := logs.CreateLogger("remote.get")
+
+	fileURL := c.baseURL.ResolveReference(&url.URL{Path: relativePath}).String()
+	cached, entry, cacheErr := c.readCache(fileURL)
+
+	if c.offline {
+		if cacheErr != nil {
+			logger.Errorf("%s is not cached in offline mode", fileURL)
+			return nil, fmt.Errorf("%w: %s", ErrNotCached, fileURL)
+		}
+		return cached, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRequestFailed, err)
+	}
+	if cacheErr == nil {
+		if entry.ETag != "" {
+			request.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			request.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	logger.Infof("GET %s", fileURL)
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		if cacheErr == nil && ctx.Err() == nil {
+			logger.Warnf("failed to download %s, using cached copy: %v", fileURL, err)
+			return cached, nil
+		}
+		err = network.DescribeError(err)
+		message := fmt.Sprintf("failed to download %s", fileURL)
+		logger.Errorf("%s: %v", message, err)
+		return nil, fmt.Errorf("%w: %s: %v", ErrRequestFailed, message, err)
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && cacheErr == nil:
+		logger.Debugf("%s is not modified", fileURL)
+		return cached, nil
+
+	case response.StatusCode == http.StatusOK:
+		contents, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read %s: %v", ErrRequestFailed, fileURL, err)
+		}
+		c.writeCache(fileURL, contents, cacheEntry{
+			ETag:         response.Header.Get("ETag"),
+			LastModified: response.Header.Get("Last-Modified"),
+		})
+		return contents, nil
+
+	case response.StatusCode >= http.StatusInternalServerError && cacheErr == nil:
+		logger.Warnf("%s returned %s, using cached copy", fileURL, response.Status)
+		return cached, nil
+	}
+
+	logger.Errorf("%s returned %s", fileURL, response.Status)
+	return nil, fmt.Errorf("%w: %s returned %s", ErrRequestFailed, fileURL, response.Status)
+}
+
+// cachePaths returns the files storing the contents and the validators of
+// [fileURL]
+func (c *Client) cachePaths(fileURL string) (string, string) {
+	sum := sha256.Sum256([]byte(fileURL))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.cacheDir, key), filepath.Join(c.cacheDir, key+".json")
+}
+
+func (c *Client) readCache(fileURL string) ([]byte, cacheEntry, error) {
+	if c.cacheDir == "" {
+		return nil, cacheEntry{}, ErrNotCached
+	}
+
+	dataPath, entryPath := c.cachePaths(fileURL)
+	contents, err := ioutil.ReadFile(dataPath)
+	if err != nil {
+		return nil, cacheEntry{}, err
+	}
+
+	entry := cacheEntry{}
+	if rawEntry, err := ioutil.ReadFile(entryPath); err == nil {
+		json.Unmarshal(rawEntry, &entry)
+	}
+
+	return contents, entry, nil
+}
+
+// writeCache stores [contents] of [fileURL]. Failures only cost a download
+// next time, so they are logged and otherwise ignored.
+func (c *Client) writeCache(fileURL string, contents []byte, entry cacheEntry) {
+	logger := logs.CreateLogger("remote.cache")
+	if c.cacheDir == "" {
+		return
+	}
+
+	if err := os.MkdirAll(c.cacheDir, 0755); err != nil {
+		logger.Warnf("failed to create cache directory: %v", err)
+		return
+	}
+
+	// The contents are written first: stale validators next to fresh
+	// contents only cost a download, the other way around serves stale files
+	dataPath, entryPath := c.cachePaths(fileURL)
+	if err := utils.WriteFileAtomic(dataPath, contents); err != nil {
+		logger.Warnf("failed to cache %s: %v", fileURL, err)
+		return
+	}
+	rawEntry, _ := json.Marshal(entry)
+	if err := utils.WriteFileAtomic(entryPath, rawEntry); err != nil {
+		logger.Warnf("failed to cache %s: %v", fileURL, err)
+	}
+}
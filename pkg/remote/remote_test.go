@@ -0,0 +1,207 @@
+package remote_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haroldadmin/getignore/pkg/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+// testServer serves an index and templates with ETag and Last-Modified
+// validators, and counts the full responses it sends
+type testServer struct {
+	*httptest.Server
+
+	mutex     sync.Mutex
+	files     map[string]string
+	downloads map[string]int
+	// noETags makes the server only send Last-Modified validators
+	noETags bool
+}
+
+func newTestServer(t *testing.T, files map[string]string) *testServer {
+	t.Helper()
+
+	server := &testServer{files: files, downloads: map[string]int{}}
+	modified := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+
+		contents, ok := server.files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		if server.noETags {
+			if r.Header.Get("If-Modified-Since") == modified.Format(http.TimeFormat) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		} else {
+			etag := fmt.Sprintf(`"%x"`, sha1.Sum([]byte(contents)))
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		server.downloads[r.URL.Path]++
+		w.Write([]byte(contents))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *testServer) set(path string, contents string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.files[path] = contents
+}
+
+func (s *testServer) downloadCount(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.downloads[path]
+}
+
+func testFiles() map[string]string {
+	return map[string]string{
+		"/gitignore/index.json":             `["Go.gitignore", "Global/macOS.gitignore"]`,
+		"/gitignore/Go.gitignore":           "*.exe\n",
+		"/gitignore/Global/macOS.gitignore": ".DS_Store\n",
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Run("it should list templates from the index", func(t *testing.T) {
+		server := newTestServer(t, testFiles())
+		client, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore", CacheDir: t.TempDir()})
+		assert.NoError(t, err)
+
+		paths, err := client.Index(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Go.gitignore", "Global/macOS.gitignore"}, paths)
+	})
+
+	t.Run("it should download templates", func(t *testing.T) {
+		server := newTestServer(t, testFiles())
+		client, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore/", CacheDir: t.TempDir()})
+		assert.NoError(t, err)
+
+		contents, err := client.Fetch(context.Background(), "Global/macOS.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, ".DS_Store\n", string(contents))
+	})
+
+	t.Run("it should not download unchanged templates again", func(t *testing.T) {
+		server := newTestServer(t, testFiles())
+		client, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore", CacheDir: t.TempDir()})
+		assert.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			contents, err := client.Fetch(context.Background(), "Go.gitignore")
+			assert.NoError(t, err)
+			assert.Equal(t, "*.exe\n", string(contents))
+		}
+		assert.Equal(t, 1, server.downloadCount("/gitignore/Go.gitignore"))
+
+		server.set("/gitignore/Go.gitignore", "*.test\n")
+		contents, err := client.Fetch(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "*.test\n", string(contents))
+		assert.Equal(t, 2, server.downloadCount("/gitignore/Go.gitignore"))
+	})
+
+	t.Run("it should revalidate with If-Modified-Since without ETags", func(t *testing.T) {
+		server := newTestServer(t, testFiles())
+		server.noETags = true
+		client, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore", CacheDir: t.TempDir()})
+		assert.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			contents, err := client.Fetch(context.Background(), "Go.gitignore")
+			assert.NoError(t, err)
+			assert.Equal(t, "*.exe\n", string(contents))
+		}
+		assert.Equal(t, 1, server.downloadCount("/gitignore/Go.gitignore"))
+	})
+
+	t.Run("it should use cached templates when the server is unreachable", func(t *testing.T) {
+		server := newTestServer(t, testFiles())
+		cacheDir := t.TempDir()
+		client, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore", CacheDir: cacheDir})
+		assert.NoError(t, err)
+
+		_, err = client.Fetch(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		server.Close()
+
+		contents, err := client.Fetch(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "*.exe\n", string(contents))
+
+		_, err = client.Fetch(context.Background(), "Global/macOS.gitignore")
+		assert.True(t, errors.Is(err, remote.ErrRequestFailed))
+	})
+
+	t.Run("it should only use the cache in offline mode", func(t *testing.T) {
+		server := newTestServer(t, testFiles())
+		cacheDir := t.TempDir()
+		client, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore", CacheDir: cacheDir})
+		assert.NoError(t, err)
+		_, err = client.Fetch(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+
+		offline, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore", CacheDir: cacheDir, Offline: true})
+		assert.NoError(t, err)
+
+		contents, err := offline.Fetch(context.Background(), "Go.gitignore")
+		assert.NoError(t, err)
+		assert.Equal(t, "*.exe\n", string(contents))
+		assert.Equal(t, 1, server.downloadCount("/gitignore/Go.gitignore"))
+
+		_, err = offline.Fetch(context.Background(), "Global/macOS.gitignore")
+		assert.True(t, errors.Is(err, remote.ErrNotCached))
+	})
+
+	t.Run("it should return an error for missing templates", func(t *testing.T) {
+		server := newTestServer(t, testFiles())
+		client, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore", CacheDir: t.TempDir()})
+		assert.NoError(t, err)
+
+		_, err = client.Fetch(context.Background(), "Missing.gitignore")
+		assert.True(t, errors.Is(err, remote.ErrRequestFailed))
+	})
+
+	t.Run("it should reject paths outside of the base URL", func(t *testing.T) {
+		server := newTestServer(t, map[string]string{
+			"/gitignore/index.json": `["Go.gitignore", "../secret"]`,
+		})
+		client, err := remote.New(remote.Options{BaseURL: server.URL + "/gitignore", CacheDir: t.TempDir()})
+		assert.NoError(t, err)
+
+		_, err = client.Index(context.Background())
+		assert.True(t, errors.Is(err, remote.ErrInvalidIndex))
+
+		_, err = client.Fetch(context.Background(), "../secret")
+		assert.True(t, errors.Is(err, remote.ErrInvalidPath))
+	})
+
+	t.Run("it should reject invalid base URLs", func(t *testing.T) {
+		_, err := remote.New(remote.Options{BaseURL: "ftp://example.com/gitignore"})
+		assert.True(t, errors.Is(err, remote.ErrInvalidBaseURL))
+	})
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes [contents] to a temporary file and renames it to
+// [filePath], so that concurrent readers never see partial files
+func WriteFileAtomic(filePath string, contents []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(contents)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return os.Rename(file.Name(), filePath)
+}
@@ -0,0 +1,36 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/haroldadmin/getignore/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Run("it should replace the contents of the file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "file.json")
+		assert.NoError(t, utils.WriteFileAtomic(filePath, []byte("old")))
+		assert.NoError(t, utils.WriteFileAtomic(filePath, []byte("new")))
+
+		contents, err := ioutil.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", string(contents))
+	})
+
+	t.Run("it should not leave temporary files behind", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, utils.WriteFileAtomic(filepath.Join(dir, "file.json"), []byte("contents")))
+
+		entries, err := ioutil.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("it should fail when the directory does not exist", func(t *testing.T) {
+		err := utils.WriteFileAtomic(filepath.Join(t.TempDir(), "missing", "file.json"), nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,32 @@
+package utils
+
+import "fmt"
+
+// DateFormat is the layout of dates shown by commands, such as
+// "2024-01-02 15:04"
+const DateFormat = "2006-01-02 15:04"
+
+// FormatBytes formats a size in bytes for humans, such as "1.5 MiB"
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value, suffix := float64(bytes)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+// ShortHash abbreviates a commit or blob hash to 7 characters, like git
+func ShortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,27 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/haroldadmin/getignore/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBytes(t *testing.T) {
+	t.Run("it should format sizes with binary units", func(t *testing.T) {
+		assert.Equal(t, "512 B", utils.FormatBytes(512))
+		assert.Equal(t, "1.5 KiB", utils.FormatBytes(1536))
+		assert.Equal(t, "2.0 MiB", utils.FormatBytes(2*1024*1024))
+		assert.Equal(t, "3.0 GiB", utils.FormatBytes(3*1024*1024*1024))
+	})
+}
+
+func TestShortHash(t *testing.T) {
+	t.Run("it should abbreviate hashes to 7 characters", func(t *testing.T) {
+		assert.Equal(t, "0123456", utils.ShortHash("0123456789abcdef"))
+	})
+
+	t.Run("it should keep short hashes", func(t *testing.T) {
+		assert.Equal(t, "0123", utils.ShortHash("0123"))
+	})
+}